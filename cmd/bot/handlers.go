@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+func handlerPause(gs *gamelogic.GameState) func(routing.PlayingState) pubsub.AckType {
+	return func(ps routing.PlayingState) pubsub.AckType {
+		gs.HandlePause(ps)
+		return pubsub.Ack
+	}
+}
+
+func handlerTurn(gs *gamelogic.GameState) func(routing.TurnState) pubsub.AckType {
+	return func(ts routing.TurnState) pubsub.AckType {
+		gs.HandleTurn(ts)
+		return pubsub.Ack
+	}
+}
+
+func handlerElimination(gs *gamelogic.GameState) func(routing.PlayerEliminated) pubsub.AckType {
+	return func(pe routing.PlayerEliminated) pubsub.AckType {
+		gs.HandleElimination(pe)
+		if pe.Username == gs.GetUsername() {
+			log.Printf("%s has been eliminated.", pe.Username)
+		}
+		return pubsub.Ack
+	}
+}
+
+func handlerGameOver(gs *gamelogic.GameState) func(routing.GameOver) pubsub.AckType {
+	return func(over routing.GameOver) pubsub.AckType {
+		gs.HandleGameOver(over)
+		if over.Winner == gs.GetUsername() {
+			log.Printf("%s won the game: %s", gs.GetUsername(), over.Reason)
+		}
+		return pubsub.Ack
+	}
+}
+
+// handlerAdmin stops the bot when an admin kicks or bans it.
+func handlerAdmin(p *botPlayer) func(routing.AdminEvent) pubsub.AckType {
+	return func(ev routing.AdminEvent) pubsub.AckType {
+		if p.gs.HandleAdmin(ev) {
+			log.Printf("%s was removed by an admin (%s): %s", p.gs.GetUsername(), ev.Action, ev.Reason)
+			p.removed.Store(true)
+			p.channel.Close()
+		}
+		return pubsub.Ack
+	}
+}
+
+func handlerNewGame(p *botPlayer) func(routing.NewGame) pubsub.AckType {
+	return func(ng routing.NewGame) pubsub.AckType {
+		p.gs.HandleNewGame(ng)
+		p.publishArmyState()
+		return pubsub.Ack
+	}
+}
+
+func handlerMove(p *botPlayer) func(gamelogic.ArmyMove) pubsub.AckType {
+	return func(am gamelogic.ArmyMove) pubsub.AckType {
+		moveOutcome := p.gs.HandleMove(am)
+		if moveOutcome == gamelogic.MoveOutComeSafe {
+			return pubsub.Ack
+		} else if moveOutcome == gamelogic.MoveOutcomeMakeWar {
+			rOW := gamelogic.RecognitionOfWar{
+				Attacker: am.Player,
+				Defender: gamelogic.FilterPlayerFor(p.gs.GetPlayerSnap(), am.Player),
+				Mode:     p.gs.GetCombatMode(),
+				Seed:     time.Now().UnixNano(),
+			}
+			err := pubsub.PublishJSON(p.channel, routing.ExchangePerilTopic, routing.GameKey(p.gs.GetGameID(), routing.WarRecognitionsPrefix)+"."+p.gs.GetUsername(), rOW)
+			if err != nil {
+				return pubsub.NackRequeue
+			}
+			return pubsub.Ack
+		}
+		return pubsub.NackDiscard
+	}
+}
+
+func handlerWar(p *botPlayer) func(gamelogic.RecognitionOfWar) pubsub.AckType {
+	return func(row gamelogic.RecognitionOfWar) pubsub.AckType {
+		outcome, result := p.gs.HandleWar(row)
+		switch outcome {
+		case gamelogic.WarOutcomeNotInvolved:
+			return pubsub.NackDiscard
+		case gamelogic.WarOutcomeNoUnits:
+			return pubsub.NackDiscard
+		}
+
+		err := pubsub.PublishJSON(p.channel, routing.ExchangePerilTopic, routing.GameKey(p.gs.GetGameID(), routing.WarResultsPrefix)+"."+p.gs.GetUsername(), result)
+		if err != nil {
+			log.Printf("%s could not publish a war result: %v", p.gs.GetUsername(), err)
+		}
+		p.publishArmyState()
+		p.publishTerritoryChanges()
+
+		winner, loser := result.Attacker, result.Defender
+		if outcome == gamelogic.WarOutcomeOpponentWon {
+			winner, loser = loser, winner
+		}
+		msg := fmt.Sprintf("%s won a war against %s", winner, loser)
+		if outcome == gamelogic.WarOutcomeDraw {
+			msg = fmt.Sprintf("A war between %s and %s resulted in a draw", winner, loser)
+		}
+		if err := p.publishGameLog(msg); err != nil {
+			log.Printf("%s could not publish a game log: %v", p.gs.GetUsername(), err)
+		}
+		return pubsub.Ack
+	}
+}
+
+func handlerWarResult(p *botPlayer) func(gamelogic.WarResult) pubsub.AckType {
+	return func(wr gamelogic.WarResult) pubsub.AckType {
+		if p.gs.HandleWarResult(wr) {
+			p.publishArmyState()
+		}
+		p.publishTerritoryChanges()
+		return pubsub.Ack
+	}
+}
+
+func handlerTerritory(gs *gamelogic.GameState) func(gamelogic.TerritoryChange) pubsub.AckType {
+	return func(tc gamelogic.TerritoryChange) pubsub.AckType {
+		gs.HandleTerritoryChange(tc)
+		return pubsub.Ack
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/bot"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/config"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+func main() {
+	count := flag.Int("n", 1, "number of bots to run")
+	prefix := flag.String("name", "bot", "username prefix for the bots")
+	strategies := flag.String("strategy", "random", "comma separated strategies, assigned to bots in turn: "+strings.Join(bot.StrategyNames(), ", "))
+	difficultyName := flag.String("difficulty", string(bot.DifficultyNormal), "easy, normal or hard")
+	think := flag.Duration("think", 2*time.Second, "average time a bot waits between commands")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "random seed for the bots")
+	password := flag.String("password", "peril-bot", "password the bots sign in with, new bots are registered with it")
+	verbose := flag.Bool("verbose", false, "print every bot's game output")
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatalf("Trouble loading config: %v", err)
+	}
+	if cfg.GameID == "" {
+		cfg.GameID = routing.DefaultGameID
+	}
+	pubsub.SetPrefetch(cfg.Prefetch)
+
+	difficulty, err := bot.ParseDifficulty(*difficultyName)
+	if err != nil {
+		log.Fatalf("Bad difficulty: %v", err)
+	}
+	strategyNames := strings.Split(*strategies, ",")
+	for _, name := range strategyNames {
+		if _, err := bot.NewStrategy(name); err != nil {
+			log.Fatalf("Bad strategy: %v", err)
+		}
+	}
+	if !routing.IsValidGameID(cfg.GameID) {
+		log.Fatalf("Bad game: %q is not a valid game ID", cfg.GameID)
+	}
+	fmt.Println("Starting Peril bots...")
+	newConnection, err := cfg.Dial()
+	if err != nil {
+		log.Fatalf("Trouble dialing rabbitMQ: %v", err)
+	}
+	defer newConnection.Close()
+
+	for i := 0; i < *count; i++ {
+		strategy, _ := bot.NewStrategy(strategyNames[i%len(strategyNames)])
+		username := fmt.Sprintf("%s%d", *prefix, i+1)
+		gs := gamelogic.NewGameState(username)
+		gs.SetGameID(cfg.GameID)
+		if !*verbose {
+			gs.SetOutput(io.Discard)
+		}
+		player, err := newBotPlayer(newConnection, username, *password, bot.New(gs, strategy, difficulty, *seed+int64(i)))
+		if err != nil {
+			log.Fatalf("Trouble starting %s: %v", username, err)
+		}
+		log.Printf("%s joined %s playing %s on %s.", username, cfg.GameID, strategy.Name(), difficulty)
+		go player.run(*think)
+	}
+
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, os.Interrupt)
+	interrupt := <-signalChan
+	fmt.Println("Bots shutting down: ", interrupt)
+}
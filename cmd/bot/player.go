@@ -0,0 +1,203 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/bot"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const lobbyTimeout = 5 * time.Second
+
+// botPlayer connects a bot to the game through the same routing keys a
+// human client uses.
+type botPlayer struct {
+	bot     *bot.Bot
+	gs      *gamelogic.GameState
+	channel *amqp.Channel
+	removed atomic.Bool
+}
+
+func newBotPlayer(conn *amqp.Connection, username, password string, b *bot.Bot) (*botPlayer, error) {
+	auth, err := pubsub.CallJSON[routing.AuthRequest, routing.AuthResponse](conn, routing.ExchangePerilDirect, routing.AuthKey, routing.AuthRequest{
+		Username: username,
+		Password: password,
+	}, lobbyTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if auth.Error != "" {
+		return nil, errors.New(auth.Error)
+	}
+
+	resp, err := pubsub.CallJSON[routing.LobbyRequest, routing.LobbyResponse](conn, routing.ExchangePerilDirect, routing.LobbyKey, routing.LobbyRequest{
+		Action:   routing.LobbyJoin,
+		GameID:   b.State.GetGameID(),
+		Username: username,
+		Token:    auth.Token,
+	}, lobbyTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	if err := gamelogic.SetUnitCatalog(resp.Units); err != nil {
+		return nil, fmt.Errorf("the server sent a bad unit catalog: %v", err)
+	}
+	if resp.Game.GameNumber > 0 {
+		b.State.SetGameNumber(resp.Game.GameNumber)
+	}
+
+	channel, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	pubsub.SignWith(channel, auth.Token)
+	p := &botPlayer{
+		bot:     b,
+		gs:      b.State,
+		channel: channel,
+	}
+	for _, ps := range resp.Pauses {
+		p.gs.HandlePause(ps)
+	}
+	return p, p.subscribe(conn, username)
+}
+
+func (p *botPlayer) subscribe(conn *amqp.Connection, username string) error {
+	gs := p.gs
+	subscriptions := []error{
+		pubsub.SubscribeJSON(conn, routing.ExchangePerilDirect, routing.GameKey(gs.GetGameID(), routing.PauseKey)+"."+username, routing.GameKey(gs.GetGameID(), routing.PauseKey), pubsub.Transient, handlerPause(gs)),
+		pubsub.SubscribeJSON(conn, routing.ExchangePerilDirect, routing.GameKey(gs.GetGameID(), routing.TurnKey)+"."+username, routing.GameKey(gs.GetGameID(), routing.TurnKey), pubsub.Transient, handlerTurn(gs)),
+		pubsub.SubscribeJSON(conn, routing.ExchangePerilDirect, routing.GameKey(gs.GetGameID(), routing.EliminatedKey)+"."+username, routing.GameKey(gs.GetGameID(), routing.EliminatedKey), pubsub.Transient, handlerElimination(gs)),
+		pubsub.SubscribeJSON(conn, routing.ExchangePerilDirect, routing.GameKey(gs.GetGameID(), routing.GameOverKey)+"."+username, routing.GameKey(gs.GetGameID(), routing.GameOverKey), pubsub.Transient, handlerGameOver(gs)),
+		pubsub.SubscribeJSON(conn, routing.ExchangePerilDirect, routing.GameKey(gs.GetGameID(), routing.NewGameKey)+"."+username, routing.GameKey(gs.GetGameID(), routing.NewGameKey), pubsub.Transient, handlerNewGame(p)),
+		pubsub.SubscribeJSON(conn, routing.ExchangePerilTopic, routing.GameKey(gs.GetGameID(), routing.VisibleMovesPrefix)+"."+username, routing.GameKey(gs.GetGameID(), routing.VisibleMovesPrefix)+"."+username, pubsub.Transient, handlerMove(p)),
+		pubsub.SubscribeJSON(conn, routing.ExchangePerilTopic, routing.GameKey(gs.GetGameID(), routing.WarDeclaredPrefix)+"."+username, routing.GameKey(gs.GetGameID(), routing.WarDeclaredPrefix)+"."+username, pubsub.Transient, handlerWar(p)),
+		pubsub.SubscribeJSON(conn, routing.ExchangePerilTopic, routing.GameKey(gs.GetGameID(), routing.VisibleWarResultsPrefix)+"."+username, routing.GameKey(gs.GetGameID(), routing.VisibleWarResultsPrefix)+"."+username, pubsub.Transient, handlerWarResult(p)),
+		pubsub.SubscribeJSON(conn, routing.ExchangePerilDirect, routing.GameKey(gs.GetGameID(), routing.AdminKey)+"."+username, routing.GameKey(gs.GetGameID(), routing.AdminKey), pubsub.Transient, handlerAdmin(p)),
+		pubsub.SubscribeJSON(conn, routing.ExchangePerilTopic, routing.GameKey(gs.GetGameID(), routing.TerritoryPrefix)+"."+username, routing.GameKey(gs.GetGameID(), routing.TerritoryPrefix)+".*", pubsub.Transient, handlerTerritory(gs)),
+	}
+	for _, err := range subscriptions {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run plays until the process exits, waiting a jittered think time between
+// commands. In turn mode the bot ends its turn after acting once per phase.
+func (p *botPlayer) run(think time.Duration) {
+	lastEnded := routing.TurnEnd{}
+	incomeTicker := time.NewTicker(gamelogic.IncomeInterval)
+	defer incomeTicker.Stop()
+	heartbeatTicker := time.NewTicker(routing.HeartbeatInterval)
+	defer heartbeatTicker.Stop()
+	for !p.removed.Load() {
+		select {
+		case <-incomeTicker.C:
+			p.gs.CollectIncomeOnInterval()
+		case <-heartbeatTicker.C:
+			p.publishHeartbeat()
+		case <-time.After(jitter(think)):
+		}
+		if p.gs.IsPaused() {
+			continue
+		}
+
+		if words := p.bot.Decide(); words != nil {
+			p.execute(words)
+		}
+
+		ts := p.gs.GetTurn()
+		turnEnd := p.gs.GetTurnEnd()
+		if ts.Enabled && (ts.ActivePlayer == "" || ts.ActivePlayer == p.gs.GetUsername()) && turnEnd != lastEnded {
+			err := pubsub.PublishJSON(p.channel, routing.ExchangePerilTopic, routing.GameKey(p.gs.GetGameID(), routing.TurnEndPrefix)+"."+p.gs.GetUsername(), turnEnd)
+			if err != nil {
+				log.Printf("%s could not end its turn: %v", p.gs.GetUsername(), err)
+				continue
+			}
+			lastEnded = turnEnd
+		}
+	}
+}
+
+func (p *botPlayer) execute(words []string) {
+	switch words[0] {
+	case "spawn":
+		if err := p.gs.CommandSpawn(words); err != nil {
+			return
+		}
+		if !p.publishArmyState() {
+			return
+		}
+	case "move":
+		armyMove, err := p.gs.CommandMove(words)
+		if err != nil {
+			return
+		}
+		err = pubsub.PublishJSON(p.channel, routing.ExchangePerilTopic, routing.GameKey(p.gs.GetGameID(), routing.ArmyMovesPrefix)+"."+p.gs.GetUsername(), armyMove)
+		if err != nil {
+			log.Printf("%s could not publish its move: %v", p.gs.GetUsername(), err)
+			return
+		}
+		p.gs.ApplyMove(armyMove)
+	}
+	p.publishTerritoryChanges()
+}
+
+// publishArmyState reports whether the server was told about the bot's
+// army.
+func (p *botPlayer) publishArmyState() bool {
+	err := pubsub.PublishJSON(p.channel, routing.ExchangePerilTopic, routing.GameKey(p.gs.GetGameID(), routing.ArmyStatePrefix)+"."+p.gs.GetUsername(), p.gs.GetPlayerSnap())
+	if err != nil {
+		log.Printf("%s could not publish its army: %v", p.gs.GetUsername(), err)
+		return false
+	}
+	return true
+}
+
+func (p *botPlayer) publishHeartbeat() {
+	err := pubsub.PublishJSON(p.channel, routing.ExchangePerilTopic, routing.GameKey(p.gs.GetGameID(), routing.HeartbeatPrefix)+"."+p.gs.GetUsername(), routing.Presence{
+		Username: p.gs.GetUsername(),
+		Status:   routing.PresenceHeartbeat,
+		At:       time.Now(),
+	})
+	if err != nil {
+		log.Printf("%s could not publish its heartbeat: %v", p.gs.GetUsername(), err)
+	}
+}
+
+func (p *botPlayer) publishTerritoryChanges() {
+	for _, tc := range p.gs.UpdateTerritories() {
+		err := pubsub.PublishJSON(p.channel, routing.ExchangePerilTopic, routing.GameKey(p.gs.GetGameID(), routing.TerritoryPrefix)+"."+p.gs.GetUsername(), tc)
+		if err != nil {
+			log.Printf("%s could not publish a territory change: %v", p.gs.GetUsername(), err)
+		}
+	}
+}
+
+func (p *botPlayer) publishGameLog(msg string) error {
+	return pubsub.PublishGob(p.channel, routing.ExchangePerilTopic, routing.GameKey(p.gs.GetGameID(), routing.GameLogSlug)+"."+p.gs.GetUsername(), routing.GameLog{
+		Username:    p.gs.GetUsername(),
+		CurrentTime: time.Now(),
+		Message:     msg,
+	})
+}
+
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d)))
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"golang.org/x/term"
+)
+
+const signInAttempts = 3
+
+func tokenPath(username string) string {
+	return filepath.Join(gamelogic.SaveDir, username+".token")
+}
+
+// signIn reserves the username with the server. It uses the token saved by
+// an earlier sign in when there is one and asks for a password otherwise.
+func signIn(conn *amqp.Connection, username string) (string, error) {
+	if data, err := os.ReadFile(tokenPath(username)); err == nil {
+		resp, err := requestSignIn(conn, routing.AuthRequest{
+			Username: username,
+			Token:    strings.TrimSpace(string(data)),
+		})
+		if err == nil {
+			return resp.Token, nil
+		}
+		log.Println("Trouble signing in with your saved token: ", err)
+	}
+
+	for i := 0; i < signInAttempts; i++ {
+		fmt.Println("Please enter your password (new players are registered with it):")
+		password := readPassword()
+		if password == "" {
+			continue
+		}
+		resp, err := requestSignIn(conn, routing.AuthRequest{
+			Username: username,
+			Password: password,
+		})
+		if err != nil {
+			log.Println("Trouble signing in: ", err)
+			continue
+		}
+		if resp.Registered {
+			fmt.Printf("Registered %s. Keep your password safe.\n", username)
+		}
+		err = os.MkdirAll(filepath.Dir(tokenPath(username)), 0755)
+		if err == nil {
+			err = os.WriteFile(tokenPath(username), []byte(resp.Token), 0600)
+		}
+		if err != nil {
+			log.Println("Trouble saving your token: ", err)
+		}
+		return resp.Token, nil
+	}
+	return "", errors.New("too many failed sign in attempts")
+}
+
+// readPassword reads a password without echoing it. Input that is not a
+// terminal, such as a pipe in a script, is read as a normal line.
+func readPassword() string {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return strings.Join(gamelogic.GetInput(), " ")
+	}
+	fmt.Print("> ")
+	password, err := term.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		log.Println("Trouble reading your password: ", err)
+		return ""
+	}
+	return strings.TrimSpace(string(password))
+}
+
+func requestSignIn(conn *amqp.Connection, req routing.AuthRequest) (routing.AuthResponse, error) {
+	resp, err := pubsub.CallJSON[routing.AuthRequest, routing.AuthResponse](conn, routing.ExchangePerilDirect, routing.AuthKey, req, lobbyTimeout)
+	if err != nil {
+		return routing.AuthResponse{}, err
+	}
+	if resp.Error != "" {
+		return routing.AuthResponse{}, errors.New(resp.Error)
+	}
+	return resp, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const lobbyTimeout = 5 * time.Second
+
+// joinLobby lets the player list, create and join games until they are in
+// one, and returns the lobby's answer for the game they joined. A configured game is joined
+// straight away, falling back to the lobby if that fails.
+func joinLobby(conn *amqp.Connection, username, token, gameID string) (routing.LobbyResponse, error) {
+	if gameID != "" {
+		resp, err := pubsub.CallJSON[routing.LobbyRequest, routing.LobbyResponse](conn, routing.ExchangePerilDirect, routing.LobbyKey, routing.LobbyRequest{
+			Action:   routing.LobbyJoin,
+			GameID:   gameID,
+			Username: username,
+			Token:    token,
+		}, lobbyTimeout)
+		if err == nil && resp.Error == "" {
+			log.Printf("Joined game %s.", resp.Game.ID)
+			return resp, nil
+		}
+		if err == nil {
+			err = errors.New(resp.Error)
+		}
+		log.Printf("Trouble joining game %s: %v", gameID, err)
+	}
+	gamelogic.PrintLobbyHelp()
+	for {
+		words := gamelogic.GetInput()
+		if len(words) == 0 {
+			continue
+		}
+		req := routing.LobbyRequest{Username: username, Token: token}
+		if words[0] == "games" {
+			req.Action = routing.LobbyList
+		} else if words[0] == "create" || words[0] == "join" {
+			req.Action = words[0]
+			req.GameID = routing.DefaultGameID
+			if len(words) > 1 {
+				req.GameID = words[1]
+			}
+		} else if words[0] == "help" {
+			gamelogic.PrintLobbyHelp()
+			continue
+		} else if words[0] == "quit" {
+			return routing.LobbyResponse{}, errors.New("you left the lobby")
+		} else {
+			log.Println("Sorry I do not understand the request.")
+			continue
+		}
+
+		resp, err := pubsub.CallJSON[routing.LobbyRequest, routing.LobbyResponse](conn, routing.ExchangePerilDirect, routing.LobbyKey, req, lobbyTimeout)
+		if err != nil {
+			log.Println("Trouble reaching the lobby: ", err)
+			continue
+		}
+		if resp.Error != "" {
+			log.Println("Trouble with the lobby: ", resp.Error)
+			continue
+		}
+		if req.Action == routing.LobbyList {
+			gamelogic.PrintGames(resp.Games)
+			continue
+		}
+		log.Printf("Joined game %s.", resp.Game.ID)
+		return resp, nil
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+func main() {
+	username := flag.String("user", "", "player whose event log to replay")
+	gameID := flag.String("game-id", routing.DefaultGameID, "game the player's event log belongs to")
+	path := flag.String("file", "", "event log to replay, defaults to the player's log")
+	game := flag.Int("game", 0, "game to replay counting from 1, defaults to the last finished game")
+	all := flag.Bool("all", false, "print every round without waiting for enter")
+	flag.Parse()
+
+	if *path == "" && *username == "" {
+		log.Fatal("Pass -user or -file to pick an event log.")
+	}
+	if *path == "" {
+		*path = gamelogic.EventLogPath(*gameID, *username)
+	}
+	if *username == "" {
+		*username = strings.TrimSuffix(filepath.Base(*path), filepath.Ext(*path))
+	}
+
+	records, err := gamelogic.ReadEventLog(*path)
+	if err != nil {
+		log.Fatalf("Could not read event log: %v", err)
+	}
+	games := splitGames(records)
+	if len(games) == 0 {
+		log.Fatalf("%s has no events.", *path)
+	}
+	index, err := pickGame(games, *game)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Everything before the chosen game is folded silently, so the replay
+	// starts from the state the player was actually in.
+	gs := gamelogic.NewGameState(*username)
+	for _, g := range games[:index] {
+		for _, rec := range g {
+			e, err := gamelogic.DecodeEvent(rec)
+			if err != nil {
+				log.Fatal(err)
+			}
+			gs.ApplyEvent(e)
+		}
+	}
+
+	fmt.Printf("Replaying game %d of %d for %s\n", index+1, len(games), *username)
+	input := bufio.NewScanner(os.Stdin)
+	rounds := splitRounds(games[index])
+	step := 0
+	for i, round := range rounds {
+		fmt.Println()
+		if round[0].Round == 0 {
+			step++
+			fmt.Printf("==== Free play, step %d ====\n", step)
+		} else {
+			fmt.Printf("==== Round %d ====\n", round[0].Round)
+		}
+		for _, rec := range round {
+			e, err := gamelogic.DecodeEvent(rec)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("%s %s\n", rec.At.Format(time.TimeOnly), e)
+			gs.ApplyEvent(e)
+		}
+		fmt.Println("------------------------")
+		gs.CommandStatus()
+		gs.CommandMap()
+
+		if *all || i == len(rounds)-1 {
+			continue
+		}
+		fmt.Print("Press enter to continue, or q to quit: ")
+		if !input.Scan() || strings.TrimSpace(input.Text()) == "q" {
+			return
+		}
+	}
+}
+
+// splitGames starts a new game at every reset. Events before the first reset
+// belong to the game the player joined.
+func splitGames(records []gamelogic.EventRecord) [][]gamelogic.EventRecord {
+	games := [][]gamelogic.EventRecord{}
+	current := []gamelogic.EventRecord{}
+	for _, rec := range records {
+		if rec.Kind == gamelogic.EventGameReset && len(current) > 0 {
+			games = append(games, current)
+			current = []gamelogic.EventRecord{}
+		}
+		current = append(current, rec)
+	}
+	if len(current) > 0 {
+		games = append(games, current)
+	}
+	return games
+}
+
+// Free play has no rounds to step through, so it is cut into steps instead:
+// a new step starts after a pause in play or once a step is long enough to
+// fill the screen.
+const (
+	freePlayGap      = 30 * time.Second
+	freePlayStepSize = 20
+)
+
+// splitRounds groups records by round. Free play (round 0) is split further
+// into steps.
+func splitRounds(records []gamelogic.EventRecord) [][]gamelogic.EventRecord {
+	rounds := [][]gamelogic.EventRecord{}
+	for i, rec := range records {
+		if i == 0 || rec.Round != records[i-1].Round || rec.Round == 0 && newFreePlayStep(rounds[len(rounds)-1], rec) {
+			rounds = append(rounds, []gamelogic.EventRecord{})
+		}
+		rounds[len(rounds)-1] = append(rounds[len(rounds)-1], rec)
+	}
+	return rounds
+}
+
+func newFreePlayStep(step []gamelogic.EventRecord, rec gamelogic.EventRecord) bool {
+	return len(step) >= freePlayStepSize || rec.At.Sub(step[len(step)-1].At) > freePlayGap
+}
+
+// pickGame returns the index of the requested game, or of the last finished
+// game when none was requested.
+func pickGame(games [][]gamelogic.EventRecord, game int) (int, error) {
+	if game > 0 {
+		if game > len(games) {
+			return 0, fmt.Errorf("the log only has %d game(s)", len(games))
+		}
+		return game - 1, nil
+	}
+	for i := len(games) - 1; i >= 0; i-- {
+		for _, rec := range games[i] {
+			if rec.Kind == gamelogic.EventGameEnded {
+				return i, nil
+			}
+		}
+	}
+	return len(games) - 1, nil
+}
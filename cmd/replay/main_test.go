@@ -0,0 +1,37 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+)
+
+func TestSplitRoundsStepsThroughFreePlay(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	records := []gamelogic.EventRecord{}
+	add := func(round int, after time.Duration) {
+		records = append(records, gamelogic.EventRecord{Seq: len(records) + 1, At: start.Add(after), Round: round})
+	}
+	// A burst of free play, a long pause, a burst too long for one step,
+	// then two rounds.
+	add(0, 0)
+	add(0, time.Second)
+	add(0, 2*time.Minute)
+	for i := 0; i < freePlayStepSize; i++ {
+		add(0, 2*time.Minute+time.Duration(i)*time.Second)
+	}
+	add(1, 3*time.Minute)
+	add(1, 10*time.Minute)
+	add(2, 11*time.Minute)
+
+	sizes := []int{}
+	for _, round := range splitRounds(records) {
+		sizes = append(sizes, len(round))
+	}
+	want := []int{2, freePlayStepSize, 1, 2, 1}
+	if !slices.Equal(sizes, want) {
+		t.Errorf("steps have %v events, want %v", sizes, want)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+// announce sends a server announcement to everyone in the game, or only to
+// the player named by to, and keeps a copy in the game log.
+func (g *game) announce(to, text string) error {
+	a := routing.Announcement{
+		From:   routing.ServerUsername,
+		To:     to,
+		Text:   text,
+		SentAt: time.Now(),
+	}
+	key := routing.GameKey(g.id, routing.AnnouncementKey)
+	if to != "" {
+		key = routing.GameKey(g.id, routing.AnnouncementPrivatePrefix) + "." + to
+	}
+	err := pubsub.PublishJSON(g.channel, routing.ExchangePerilDirect, key, a)
+	if err != nil {
+		return err
+	}
+
+	message := "announced: " + text
+	if to != "" {
+		message = fmt.Sprintf("announced to %s: %s", to, text)
+	}
+	return gamelogic.WriteLog(routing.GameLog{
+		CurrentTime: a.SentAt,
+		Message:     message,
+		Username:    a.From,
+	})
+}
+
+// commandAnnounce handles "announce [to <username>] <text>".
+func commandAnnounce(g *game, words []string) error {
+	args := words[1:]
+	to := ""
+	if len(args) > 2 && args[0] == "to" {
+		to = args[1]
+		args = args[2:]
+	}
+	if len(args) == 0 {
+		return errors.New("usage: announce [to <username>] <text>")
+	}
+	if to == "" {
+		log.Printf("Announcing to game %s.", g.id)
+	} else {
+		log.Printf("Announcing to %s in game %s.", to, g.id)
+	}
+	return g.announce(to, strings.Join(args, " "))
+}
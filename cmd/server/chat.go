@@ -0,0 +1,257 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/ratelimit"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	chatHistorySize = 50
+	chatMaxLength   = 280
+	chatRate        = 1
+	chatBurst       = 5
+)
+
+// chatHook inspects or rewrites a message before it is delivered. Returning
+// an error rejects the message and the error is sent back to the sender.
+type chatHook func(msg *routing.ChatMessage) error
+
+// chatRoom moderates player chat and delivers it to the chat channels.
+// Teams are assigned by the server admin, never chosen by players, so team
+// chat only reaches the players the admin put on the team.
+type chatRoom struct {
+	mu      sync.Mutex
+	gameID  string
+	publish func(key string, msg routing.ChatMessage) error
+	limiter *ratelimit.Limiter
+	hooks   []chatHook
+	history []routing.ChatMessage
+	teams   map[string]string
+}
+
+func newChatRoom(gameID string, channel *amqp.Channel) *chatRoom {
+	return &chatRoom{
+		gameID: gameID,
+		publish: func(key string, msg routing.ChatMessage) error {
+			return pubsub.PublishJSON(channel, routing.ExchangePerilTopic, key, msg)
+		},
+		limiter: ratelimit.NewLimiter(chatRate, chatBurst),
+		hooks:   []chatHook{hookRequireText, hookMaxLength},
+		teams:   map[string]string{},
+	}
+}
+
+func (cr *chatRoom) addHook(hook chatHook) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	cr.hooks = append(cr.hooks, hook)
+}
+
+func hookRequireText(msg *routing.ChatMessage) error {
+	msg.Text = strings.TrimSpace(msg.Text)
+	if msg.Text == "" {
+		return fmt.Errorf("empty messages are not allowed")
+	}
+	return nil
+}
+
+// hookMaxLength cuts long messages to chatMaxLength characters, never in
+// the middle of one.
+func hookMaxLength(msg *routing.ChatMessage) error {
+	if utf8.RuneCountInString(msg.Text) > chatMaxLength {
+		msg.Text = string([]rune(msg.Text)[:chatMaxLength]) + "..."
+	}
+	return nil
+}
+
+// handle moderates and delivers a message. The sender is taken from the
+// routing key the message was published with, never from the message.
+func (cr *chatRoom) handle(key string, msg routing.ChatMessage) pubsub.AckType {
+	msg.From = usernameFromKey(key)
+	switch msg.Channel {
+	case routing.ChatChannelHistory:
+		cr.sendHistory(msg.From)
+		return pubsub.Ack
+	case routing.ChatChannelJoinTeam:
+		cr.notify(msg.From, "teams are assigned by the server admin")
+		return pubsub.NackDiscard
+	}
+
+	if !cr.limiter.Allow(msg.From) {
+		cr.notify(msg.From, "you are sending messages too quickly")
+		return pubsub.NackDiscard
+	}
+
+	cr.mu.Lock()
+	hooks := cr.hooks
+	cr.mu.Unlock()
+	for _, hook := range hooks {
+		if err := hook(&msg); err != nil {
+			cr.notify(msg.From, fmt.Sprintf("your message was not sent: %v", err))
+			return pubsub.NackDiscard
+		}
+	}
+
+	var to string
+	switch msg.Channel {
+	case routing.ChatChannelGlobal:
+		to = routing.GameKey(cr.gameID, routing.ChatGlobalKey)
+		cr.remember(msg)
+	case routing.ChatChannelPrivate:
+		to = cr.privateKey(msg.To)
+	case routing.ChatChannelTeam:
+		return cr.sendTeam(msg)
+	default:
+		log.Printf("Unknown chat channel %s from %s", msg.Channel, msg.From)
+		return pubsub.NackDiscard
+	}
+
+	err := cr.publish(to, msg)
+	if err != nil {
+		log.Printf("Error delivering chat message: %v", err)
+		return pubsub.NackRequeue
+	}
+	if msg.Channel == routing.ChatChannelPrivate && msg.To != msg.From {
+		err = cr.publish(cr.privateKey(msg.From), msg)
+		if err != nil {
+			log.Printf("Error echoing chat message: %v", err)
+		}
+	}
+	return pubsub.Ack
+}
+
+// setTeam puts a player on a team, or takes them off theirs when team is
+// empty, and tells them.
+func (cr *chatRoom) setTeam(username, team string) {
+	cr.mu.Lock()
+	if team == "" {
+		delete(cr.teams, username)
+	} else {
+		cr.teams[username] = team
+	}
+	cr.mu.Unlock()
+
+	err := cr.publish(cr.privateKey(username), routing.ChatMessage{
+		From:    routing.ServerUsername,
+		To:      username,
+		Team:    team,
+		Channel: routing.ChatChannelJoinTeam,
+		SentAt:  time.Now(),
+	})
+	if err != nil {
+		log.Printf("Error telling %s about their team: %v", username, err)
+	}
+}
+
+// team returns the team the player is on, if any.
+func (cr *chatRoom) team(username string) string {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	return cr.teams[username]
+}
+
+// sendTeam delivers a team message privately to every member of the
+// sender's team, so players on other teams never receive it.
+func (cr *chatRoom) sendTeam(msg routing.ChatMessage) pubsub.AckType {
+	cr.mu.Lock()
+	msg.Team = cr.teams[msg.From]
+	members := []string{}
+	for username, team := range cr.teams {
+		if msg.Team != "" && team == msg.Team {
+			members = append(members, username)
+		}
+	}
+	cr.mu.Unlock()
+	if msg.Team == "" {
+		cr.notify(msg.From, "you are not on a team, ask the server admin to put you on one")
+		return pubsub.NackDiscard
+	}
+	for _, username := range members {
+		err := cr.publish(cr.privateKey(username), msg)
+		if err != nil {
+			log.Printf("Error delivering team message to %s: %v", username, err)
+		}
+	}
+	return pubsub.Ack
+}
+
+func (cr *chatRoom) remember(msg routing.ChatMessage) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	cr.history = append(cr.history, msg)
+	if len(cr.history) > chatHistorySize {
+		cr.history = cr.history[len(cr.history)-chatHistorySize:]
+	}
+}
+
+func (cr *chatRoom) sendHistory(username string) {
+	cr.mu.Lock()
+	history := append([]routing.ChatMessage{}, cr.history...)
+	cr.mu.Unlock()
+	if len(history) == 0 {
+		cr.notify(username, "there is no chat history yet")
+		return
+	}
+	for _, msg := range history {
+		msg.History = true
+		err := cr.publish(cr.privateKey(username), msg)
+		if err != nil {
+			log.Printf("Error sending chat history: %v", err)
+			return
+		}
+	}
+}
+
+// notify sends a private message from the server to a player.
+func (cr *chatRoom) notify(username, text string) {
+	err := cr.publish(cr.privateKey(username), routing.ChatMessage{
+		From:    routing.ServerUsername,
+		To:      username,
+		Channel: routing.ChatChannelPrivate,
+		Text:    text,
+		SentAt:  time.Now(),
+	})
+	if err != nil {
+		log.Printf("Error notifying %s: %v", username, err)
+	}
+}
+
+// commandTeam handles "team <username> [team]". Without a team the player
+// is taken off theirs.
+func commandTeam(g *game, words []string) error {
+	if len(words) < 2 || len(words) > 3 {
+		return errors.New("usage: team <username> [team]")
+	}
+	team := ""
+	if len(words) == 3 {
+		team = words[2]
+	}
+	g.chat.setTeam(words[1], team)
+	if team == "" {
+		log.Printf("Took %s off their team in game %s.", words[1], g.id)
+	} else {
+		log.Printf("Put %s on team %s in game %s.", words[1], team, g.id)
+	}
+	return nil
+}
+
+// privateKey is the key of a player's private chat channel.
+func (cr *chatRoom) privateKey(username string) string {
+	return routing.GameKey(cr.gameID, routing.ChatPrivatePrefix) + "." + username
+}
+
+func handlerChat(cr *chatRoom) func(string, routing.ChatMessage) pubsub.AckType {
+	return func(key string, msg routing.ChatMessage) pubsub.AckType {
+		return cr.handle(key, msg)
+	}
+}
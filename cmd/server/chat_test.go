@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+func TestHookMaxLength(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"short", "hello", "hello"},
+		{"exactly the limit", strings.Repeat("a", chatMaxLength), strings.Repeat("a", chatMaxLength)},
+		{"ascii over the limit", strings.Repeat("a", chatMaxLength+1), strings.Repeat("a", chatMaxLength) + "..."},
+		{"multibyte over the limit", strings.Repeat("é", chatMaxLength+1), strings.Repeat("é", chatMaxLength) + "..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := routing.ChatMessage{Text: tt.text}
+			if err := hookMaxLength(&msg); err != nil {
+				t.Fatal(err)
+			}
+			if msg.Text != tt.want {
+				t.Errorf("hookMaxLength() left %d characters, want %d", utf8.RuneCountInString(msg.Text), utf8.RuneCountInString(tt.want))
+			}
+			if !utf8.ValidString(msg.Text) {
+				t.Error("hookMaxLength() split a character")
+			}
+		})
+	}
+}
+
+// recordDeliveries swaps the room's publisher for one that remembers which
+// keys every message went to.
+func recordDeliveries(cr *chatRoom) map[string][]routing.ChatMessage {
+	delivered := map[string][]routing.ChatMessage{}
+	cr.publish = func(key string, msg routing.ChatMessage) error {
+		delivered[key] = append(delivered[key], msg)
+		return nil
+	}
+	return delivered
+}
+
+func TestTeamChatOnlyReachesMembers(t *testing.T) {
+	cr := newChatRoom(routing.DefaultGameID, nil)
+	delivered := recordDeliveries(cr)
+	cr.setTeam("alice", "red")
+	cr.setTeam("bob", "red")
+	cr.setTeam("carol", "blue")
+
+	inKey := func(username string) string {
+		return routing.GameKey(routing.DefaultGameID, routing.ChatInPrefix) + "." + username
+	}
+	// mallory tries to join the red team on their own.
+	if ack := cr.handle(inKey("mallory"), routing.ChatMessage{Channel: routing.ChatChannelJoinTeam, Team: "red"}); ack != pubsub.NackDiscard {
+		t.Errorf("a player choosing their own team got %v", ack)
+	}
+	clear(delivered)
+
+	if ack := cr.handle(inKey("alice"), routing.ChatMessage{Channel: routing.ChatChannelTeam, Team: "blue", Text: "attack at dawn"}); ack != pubsub.Ack {
+		t.Fatalf("team message got %v", ack)
+	}
+	for _, username := range []string{"alice", "bob"} {
+		msgs := delivered[cr.privateKey(username)]
+		if len(msgs) != 1 || msgs[0].Team != "red" {
+			t.Errorf("%s got %+v, want the message on team red", username, msgs)
+		}
+	}
+	for _, username := range []string{"carol", "mallory"} {
+		if msgs := delivered[cr.privateKey(username)]; len(msgs) != 0 {
+			t.Errorf("%s is not on team red but got %+v", username, msgs)
+		}
+	}
+}
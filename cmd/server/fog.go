@@ -0,0 +1,133 @@
+package main
+
+import (
+	"log"
+	"sync"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// handlerArmyMove forwards each move only to the players who can see it.
+// A failed forward is logged rather than requeued, since requeueing would
+// deliver the move twice to the players who already got it.
+func handlerArmyMove(gameID string, w *world, rabbitChannel *amqp.Channel) func(string, gamelogic.ArmyMove) pubsub.AckType {
+	return func(key string, move gamelogic.ArmyMove) pubsub.AckType {
+		if move.Player.Username != usernameFromKey(key) {
+			log.Printf("Dropping a move by %s published by %s", move.Player.Username, usernameFromKey(key))
+			return pubsub.NackDiscard
+		}
+		for username, visible := range w.visibleMoves(move) {
+			err := pubsub.PublishJSON(rabbitChannel, routing.ExchangePerilTopic, routing.GameKey(gameID, routing.VisibleMovesPrefix)+"."+username, visible)
+			if err != nil {
+				log.Printf("Error forwarding move to %s: %v", username, err)
+			}
+		}
+		return pubsub.Ack
+	}
+}
+
+// warFront forwards each war, published by the defender who recognised it,
+// to the attacker alone so that they can resolve it. In turn mode wars are
+// only fought in the combat phase: a war recognised earlier is held until
+// the phase begins and is then sent with the armies as they stand by then.
+type warFront struct {
+	mu      sync.Mutex
+	world   *world
+	publish func(attacker string, rw gamelogic.RecognitionOfWar) error
+	holding bool
+	held    map[[2]string]gamelogic.RecognitionOfWar
+}
+
+func newWarFront(gameID string, channel *amqp.Channel, w *world) *warFront {
+	return &warFront{
+		world: w,
+		publish: func(attacker string, rw gamelogic.RecognitionOfWar) error {
+			return pubsub.PublishJSON(channel, routing.ExchangePerilTopic, routing.GameKey(gameID, routing.WarDeclaredPrefix)+"."+attacker, rw)
+		},
+		held: map[[2]string]gamelogic.RecognitionOfWar{},
+	}
+}
+
+func (wf *warFront) handle(key string, rw gamelogic.RecognitionOfWar) pubsub.AckType {
+	if rw.Defender.Username != usernameFromKey(key) {
+		log.Printf("Dropping a war on %s published by %s", rw.Defender.Username, usernameFromKey(key))
+		return pubsub.NackDiscard
+	}
+	wf.mu.Lock()
+	defer wf.mu.Unlock()
+	if wf.holding {
+		wf.held[[2]string{rw.Attacker.Username, rw.Defender.Username}] = rw
+		return pubsub.Ack
+	}
+	wf.declare(rw)
+	return pubsub.Ack
+}
+
+// setTurn is called whenever the turn state changes. Held wars are released
+// when the combat phase begins or turn mode is switched off.
+func (wf *warFront) setTurn(ts routing.TurnState) {
+	wf.mu.Lock()
+	defer wf.mu.Unlock()
+	wf.holding = ts.Enabled && ts.Phase != routing.PhaseCombat
+	if wf.holding {
+		return
+	}
+	for pair, rw := range wf.held {
+		delete(wf.held, pair)
+		attacker, okA := wf.world.army(rw.Attacker.Username)
+		defender, okD := wf.world.army(rw.Defender.Username)
+		if !okA || !okD || len(gamelogic.OverlappingLocations(attacker, defender)) == 0 {
+			log.Printf("The war between %s and %s ended before the combat phase.", rw.Attacker.Username, rw.Defender.Username)
+			continue
+		}
+		rw.Attacker = attacker
+		rw.Defender = gamelogic.FilterPlayerFor(defender, attacker)
+		wf.declare(rw)
+	}
+}
+
+// declare sends a war to its attacker. The caller must hold wf.mu.
+func (wf *warFront) declare(rw gamelogic.RecognitionOfWar) {
+	if err := wf.publish(rw.Attacker.Username, rw); err != nil {
+		log.Printf("Error forwarding war to %s: %v", rw.Attacker.Username, err)
+	}
+}
+
+func handlerWar(wf *warFront) func(string, gamelogic.RecognitionOfWar) pubsub.AckType {
+	return func(key string, rw gamelogic.RecognitionOfWar) pubsub.AckType {
+		return wf.handle(key, rw)
+	}
+}
+
+// handlerWarResult forwards a resolved war to the defender and to each other
+// player with only the battles they can see.
+func handlerWarResult(gameID string, w *world, rabbitChannel *amqp.Channel) func(string, gamelogic.WarResult) pubsub.AckType {
+	return func(key string, wr gamelogic.WarResult) pubsub.AckType {
+		if wr.Attacker != usernameFromKey(key) {
+			log.Printf("Dropping a war result for %s published by %s", wr.Attacker, usernameFromKey(key))
+			return pubsub.NackDiscard
+		}
+		for username, visible := range w.visibleWarResults(wr) {
+			err := pubsub.PublishJSON(rabbitChannel, routing.ExchangePerilTopic, routing.GameKey(gameID, routing.VisibleWarResultsPrefix)+"."+username, visible)
+			if err != nil {
+				log.Printf("Error forwarding war result to %s: %v", username, err)
+			}
+		}
+		return pubsub.Ack
+	}
+}
+
+// handlerArmyState records a player's army as they report it.
+func handlerArmyState(w *world) func(string, gamelogic.Player) pubsub.AckType {
+	return func(key string, p gamelogic.Player) pubsub.AckType {
+		if p.Username != usernameFromKey(key) {
+			log.Printf("Dropping the army of %s published by %s", p.Username, usernameFromKey(key))
+			return pubsub.NackDiscard
+		}
+		w.recordArmy(p)
+		return pubsub.Ack
+	}
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/ratelimit"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// game is one independently hosted match. Everything it publishes and
+// consumes is namespaced by its ID.
+type game struct {
+	id        string
+	createdAt time.Time
+	channel   *amqp.Channel
+	world     *world
+	ref       *referee
+	wars      *warFront
+	turns     *turnCoordinator
+	chat      *chatRoom
+	presence  *presence
+
+	logLimiter  *ratelimit.Limiter
+	moveLimiter *ratelimit.Limiter
+
+	mu sync.Mutex
+	// pause is the pause on the whole game and playerPauses the pauses on
+	// single players, kept so players who join late see them too.
+	pause        routing.PlayingState
+	playerPauses map[string]routing.PlayingState
+}
+
+// subscribe consumes everything players publish to the game. Only messages
+// signed by the player named in the routing key get through.
+func (g *game) subscribe(conn *amqp.Connection, mod *moderator, verify pubsub.Verifier) error {
+	key := func(k string) string { return routing.GameKey(g.id, k) }
+	subscriptions := []error{
+		pubsub.SubscribeGobVerified(conn, routing.ExchangePerilTopic, key(routing.GameLogSlug), key(routing.GameLogSlug)+".*", pubsub.Durable, verify, moderated(mod, throttled(g.logLimiter, mod, "game logs", handlerGameLogs(mod)))),
+		pubsub.SubscribeJSONVerified(conn, routing.ExchangePerilTopic, key(routing.TerritoryPrefix), key(routing.TerritoryPrefix)+".*", pubsub.Durable, verify, moderated(mod, handlerTerritory(g.ref))),
+		pubsub.SubscribeJSONVerified(conn, routing.ExchangePerilTopic, key(routing.ArmyMovesPrefix), key(routing.ArmyMovesPrefix)+".*", pubsub.Durable, verify, moderated(mod, throttled(g.moveLimiter, mod, "moves", handlerArmyMove(g.id, g.world, g.channel)))),
+		pubsub.SubscribeJSONVerified(conn, routing.ExchangePerilTopic, key(routing.WarRecognitionsPrefix), key(routing.WarRecognitionsPrefix)+".*", pubsub.Durable, verify, moderated(mod, handlerWar(g.wars))),
+		pubsub.SubscribeJSONVerified(conn, routing.ExchangePerilTopic, key(routing.WarResultsPrefix), key(routing.WarResultsPrefix)+".*", pubsub.Durable, verify, moderated(mod, handlerWarResult(g.id, g.world, g.channel))),
+		pubsub.SubscribeJSONVerified(conn, routing.ExchangePerilTopic, key(routing.ArmyStatePrefix), key(routing.ArmyStatePrefix)+".*", pubsub.Durable, verify, moderated(mod, handlerArmyState(g.world))),
+		pubsub.SubscribeJSONVerified(conn, routing.ExchangePerilTopic, key(routing.TurnEndPrefix), key(routing.TurnEndPrefix)+".*", pubsub.Durable, verify, moderated(mod, handlerTurnEnd(g.turns))),
+		pubsub.SubscribeJSONVerified(conn, routing.ExchangePerilTopic, key(routing.ChatInPrefix), key(routing.ChatInPrefix)+".*", pubsub.Durable, verify, moderated(mod, handlerChat(g.chat))),
+		pubsub.SubscribeJSONVerified(conn, routing.ExchangePerilTopic, key(routing.HeartbeatPrefix), key(routing.HeartbeatPrefix)+".*", pubsub.Durable, verify, moderated(mod, handlerPresence(g.presence))),
+	}
+	for _, err := range subscriptions {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// join counts a player as online from the moment the lobby lets them in,
+// before their first heartbeat arrives.
+func (g *game) join(username string) {
+	g.presence.handle(routing.Presence{
+		Username: username,
+		Status:   routing.PresenceJoin,
+		At:       time.Now(),
+	})
+}
+
+func (g *game) info() routing.GameInfo {
+	players := g.presence.usernames()
+	gameNumber := g.ref.number()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return routing.GameInfo{
+		ID:         g.id,
+		GameNumber: gameNumber,
+		Players:    players,
+		Paused:     g.pause.IsPaused,
+		CreatedAt:  g.createdAt,
+	}
+}
+
+// maxGames caps how many games one server hosts. Every game holds a channel
+// and a set of durable queues, so players must not be able to create them
+// without limit.
+const maxGames = 16
+
+// gameHost owns every game on this server and answers lobby requests.
+type gameHost struct {
+	mu    sync.Mutex
+	conn  *amqp.Connection
+	users *userStore
+	mod   *moderator
+	games map[string]*game
+
+	// resumeTimers holds the pending auto-resume for each pause target.
+	resumeTimers map[string]*time.Timer
+}
+
+func newGameHost(conn *amqp.Connection, users *userStore, mod *moderator) *gameHost {
+	return &gameHost{
+		conn:  conn,
+		users: users,
+		mod:   mod,
+		games: map[string]*game{},
+
+		resumeTimers: map[string]*time.Timer{},
+	}
+}
+
+// create starts hosting a new game, restoring it from its last save when
+// there is one.
+func (h *gameHost) create(id string) (*game, error) {
+	if !routing.IsValidGameID(id) {
+		return nil, fmt.Errorf("%q is not a valid game ID, use letters, digits, - and _", id)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.games[id]; ok {
+		return nil, fmt.Errorf("game %s already exists", id)
+	}
+	if len(h.games) >= maxGames {
+		return nil, fmt.Errorf("the server is already hosting %d games, join one of them instead", maxGames)
+	}
+
+	channel, err := h.conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	w := newWorld()
+	ref := newReferee(id, channel, w)
+	wars := newWarFront(id, channel, w)
+	players := newPresence(id, channel)
+	g := &game{
+		id:        id,
+		createdAt: time.Now(),
+		channel:   channel,
+		world:     w,
+		ref:       ref,
+		wars:      wars,
+		turns:     newTurnCoordinator(id, channel, players.usernames, ref.handleNewRound, wars.setTurn),
+		chat:      newChatRoom(id, channel),
+		presence:  players,
+
+		logLimiter:  ratelimit.NewLimiter(gameLogRate, gameLogBurst),
+		moveLimiter: ratelimit.NewLimiter(moveRate, moveBurst),
+
+		playerPauses: map[string]routing.PlayingState{},
+	}
+
+	restoreErr := ref.load(serverSnapshotPath(id))
+	if restoreErr == nil {
+		log.Printf("Restored game %s from %s.", id, serverSnapshotPath(id))
+	} else if !errors.Is(restoreErr, os.ErrNotExist) {
+		log.Printf("Could not restore game %s: %v", id, restoreErr)
+	}
+	g.chat.addHook(hookMuted(h.mod))
+	if err := g.subscribe(h.conn, h.mod, h.users.verify); err != nil {
+		channel.Close()
+		return nil, err
+	}
+	go g.presence.watch()
+	h.games[id] = g
+	return g, nil
+}
+
+func (h *gameHost) get(id string) (*game, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	g, ok := h.games[id]
+	return g, ok
+}
+
+func (h *gameHost) list() []*game {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	games := []*game{}
+	for _, g := range h.games {
+		games = append(games, g)
+	}
+	sort.Slice(games, func(i, j int) bool { return games[i].id < games[j].id })
+	return games
+}
+
+// online reports whether username is connected to any game.
+func (h *gameHost) online(username string) bool {
+	for _, g := range h.list() {
+		for _, record := range g.presence.players() {
+			if record.username == username {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (h *gameHost) handleAuth(req routing.AuthRequest) routing.AuthResponse {
+	defer fmt.Print("> ")
+	if ban, ok := h.mod.banned(req.Username); ok {
+		log.Printf("Rejected sign in for banned player %s.", req.Username)
+		return routing.AuthResponse{Error: fmt.Sprintf("you are banned: %s", ban.Reason)}
+	}
+	if until, ok := h.mod.kicked(req.Username); ok {
+		return routing.AuthResponse{Error: fmt.Sprintf("you were kicked, try again in %v", time.Until(until).Round(time.Second))}
+	}
+	resp, err := h.users.authenticate(req, h.online)
+	if err != nil {
+		log.Printf("Rejected sign in for %s: %v", req.Username, err)
+		return routing.AuthResponse{Error: err.Error()}
+	}
+	if resp.Registered {
+		log.Printf("Registered %s.", req.Username)
+	} else {
+		log.Printf("%s signed in.", req.Username)
+	}
+	return resp
+}
+
+func (h *gameHost) handleLobby(req routing.LobbyRequest) routing.LobbyResponse {
+	defer fmt.Print("> ")
+	if req.Action != routing.LobbyList && !h.users.validToken(req.Username, req.Token) {
+		return routing.LobbyResponse{Error: "sign in before joining a game"}
+	}
+	if ban, ok := h.mod.banned(req.Username); ok {
+		return routing.LobbyResponse{Error: fmt.Sprintf("you are banned: %s", ban.Reason)}
+	}
+	if until, ok := h.mod.kicked(req.Username); ok {
+		return routing.LobbyResponse{Error: fmt.Sprintf("you were kicked, try again in %v", time.Until(until).Round(time.Second))}
+	}
+	switch req.Action {
+	case routing.LobbyList:
+		games := []routing.GameInfo{}
+		for _, g := range h.list() {
+			games = append(games, g.info())
+		}
+		return routing.LobbyResponse{Games: games}
+	case routing.LobbyCreate:
+		g, err := h.create(req.GameID)
+		if err != nil {
+			return routing.LobbyResponse{Error: err.Error()}
+		}
+		log.Printf("%s created game %s.", req.Username, g.id)
+		g.join(req.Username)
+		return routing.LobbyResponse{Game: g.info(), Pauses: g.pausesFor(req.Username), Team: g.chat.team(req.Username), Units: gamelogic.UnitCatalog()}
+	case routing.LobbyJoin:
+		g, ok := h.get(req.GameID)
+		if !ok {
+			return routing.LobbyResponse{Error: fmt.Sprintf("there is no game called %s", req.GameID)}
+		}
+		log.Printf("%s joined game %s.", req.Username, g.id)
+		g.join(req.Username)
+		return routing.LobbyResponse{Game: g.info(), Pauses: g.pausesFor(req.Username), Team: g.chat.team(req.Username), Units: gamelogic.UnitCatalog()}
+	default:
+		return routing.LobbyResponse{Error: fmt.Sprintf("unknown lobby action %s", req.Action)}
+	}
+}
+
+// kick disconnects a player from every game they are in. action is
+// routing.AdminKick or routing.AdminBan.
+func (h *gameHost) kick(username, action, reason string) {
+	h.mod.kick(username)
+	for _, g := range h.list() {
+		g.presence.remove(username)
+	}
+	h.broadcastAdmin(routing.AdminEvent{
+		Action:   action,
+		Username: username,
+		Reason:   reason,
+	})
+}
+
+// broadcastAdmin sends a moderation event to every game, so the player sees
+// it wherever they are.
+func (h *gameHost) broadcastAdmin(ev routing.AdminEvent) {
+	log.Printf("Admin: %s %s.", ev.Action, ev.Username)
+	for _, g := range h.list() {
+		err := pubsub.PublishJSON(g.channel, routing.ExchangePerilDirect, routing.GameKey(g.id, routing.AdminKey), ev)
+		if err != nil {
+			log.Printf("Error publishing admin event to game %s: %v", g.id, err)
+		}
+	}
+}
+
+func commandGames(h *gameHost, current string) {
+	fmt.Println("Games:")
+	for _, g := range h.list() {
+		info := g.info()
+		marker := " "
+		if info.ID == current {
+			marker = "*"
+		}
+		fmt.Printf("%s %s: %d player(s), paused: %v, created %s\n", marker, info.ID, len(info.Players), info.Paused, info.CreatedAt.Format(time.Kitchen))
+	}
+}
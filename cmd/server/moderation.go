@@ -0,0 +1,283 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+const banListFile = "bans.json"
+
+type banRecord struct {
+	Username string
+	Reason   string
+	BannedAt time.Time
+}
+
+// moderator keeps the persisted ban list and the in-memory mutes, which
+// apply to every game on the server.
+type moderator struct {
+	mu    sync.Mutex
+	path  string
+	bans  map[string]banRecord
+	mutes map[string]time.Time
+	kicks map[string]time.Time
+	flags map[string]*flagRecord
+}
+
+// flagRecord counts the messages a player had dropped for going over a rate
+// limit.
+type flagRecord struct {
+	username string
+	dropped  map[string]int
+	last     time.Time
+}
+
+// flagQuietPeriod keeps a flooding player from flooding the admin's console
+// in turn: they are only reported again after this long.
+const flagQuietPeriod = time.Minute
+
+// kickCooldown is how long a kicked player may not sign back in, and how
+// long the server ignores anything their client still publishes.
+const kickCooldown = 5 * time.Minute
+
+func loadModerator(path string) (*moderator, error) {
+	m := &moderator{
+		path:  path,
+		bans:  map[string]banRecord{},
+		mutes: map[string]time.Time{},
+		kicks: map[string]time.Time{},
+		flags: map[string]*flagRecord{},
+	}
+	err := gamelogic.ReadSnapshotFile(path, &m.bans)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *moderator) ban(username, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bans[username] = banRecord{
+		Username: username,
+		Reason:   reason,
+		BannedAt: time.Now(),
+	}
+	return gamelogic.WriteSnapshotFile(m.path, m.bans)
+}
+
+func (m *moderator) unban(username string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.bans[username]; !ok {
+		return fmt.Errorf("%s is not banned", username)
+	}
+	delete(m.bans, username)
+	delete(m.kicks, username)
+	return gamelogic.WriteSnapshotFile(m.path, m.bans)
+}
+
+func (m *moderator) banned(username string) (banRecord, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record, ok := m.bans[username]
+	return record, ok
+}
+
+func (m *moderator) kick(username string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.kicks[username] = time.Now().Add(kickCooldown)
+}
+
+// kicked reports whether username was kicked recently and when they may
+// come back.
+func (m *moderator) kicked(username string) (time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	until, ok := m.kicks[username]
+	if ok && time.Now().After(until) {
+		delete(m.kicks, username)
+		return time.Time{}, false
+	}
+	return until, ok
+}
+
+// silenced reports whether the server should drop everything username
+// publishes.
+func (m *moderator) silenced(username string) bool {
+	if _, ok := m.banned(username); ok {
+		return true
+	}
+	_, ok := m.kicked(username)
+	return ok
+}
+
+func (m *moderator) banList() []banRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	records := []banRecord{}
+	for _, record := range m.bans {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Username < records[j].Username })
+	return records
+}
+
+func (m *moderator) mute(username string, until time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mutes[username] = until
+}
+
+func (m *moderator) unmute(username string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.mutes, username)
+}
+
+func (m *moderator) muted(username string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	until, ok := m.mutes[username]
+	if ok && time.Now().After(until) {
+		delete(m.mutes, username)
+		return false
+	}
+	return ok
+}
+
+// flag records a dropped message and reports whether the admin should be
+// told about it.
+func (m *moderator) flag(username, what string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record, ok := m.flags[username]
+	if !ok {
+		record = &flagRecord{username: username, dropped: map[string]int{}}
+		m.flags[username] = record
+	}
+	record.dropped[what]++
+	report := time.Since(record.last) > flagQuietPeriod
+	if report {
+		record.last = time.Now()
+	}
+	return report
+}
+
+func (m *moderator) flagList() []flagRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	records := []flagRecord{}
+	for _, record := range m.flags {
+		dropped := map[string]int{}
+		for k, v := range record.dropped {
+			dropped[k] = v
+		}
+		records = append(records, flagRecord{username: record.username, dropped: dropped, last: record.last})
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].username < records[j].username })
+	return records
+}
+
+func hookMuted(m *moderator) chatHook {
+	return func(msg *routing.ChatMessage) error {
+		if m.muted(msg.From) {
+			return errors.New("you are muted")
+		}
+		return nil
+	}
+}
+
+func commandModeration(h *gameHost, words []string) error {
+	if words[0] == "bans" {
+		records := h.mod.banList()
+		if len(records) == 0 {
+			fmt.Println("Nobody is banned.")
+			return nil
+		}
+		fmt.Println("Banned players:")
+		for _, record := range records {
+			fmt.Printf("* %s since %s: %s\n", record.Username, record.BannedAt.Format(time.DateTime), record.Reason)
+		}
+		return nil
+	}
+	if words[0] == "flags" {
+		records := h.mod.flagList()
+		if len(records) == 0 {
+			fmt.Println("Nobody has been flagged.")
+			return nil
+		}
+		fmt.Println("Flagged players:")
+		for _, record := range records {
+			kinds := []string{}
+			for what, count := range record.dropped {
+				kinds = append(kinds, fmt.Sprintf("%d %s", count, what))
+			}
+			sort.Strings(kinds)
+			fmt.Printf("* %s: dropped %s, last reported %s\n", record.username, strings.Join(kinds, ", "), record.last.Format(time.TimeOnly))
+		}
+		return nil
+	}
+	if len(words) < 2 {
+		return fmt.Errorf("usage: %s <username>", words[0])
+	}
+	username := words[1]
+	reason := strings.Join(words[2:], " ")
+
+	switch words[0] {
+	case "kick":
+		h.kick(username, routing.AdminKick, orDefault(reason, "kicked by an admin"))
+	case "ban":
+		reason = orDefault(reason, "banned by an admin")
+		if err := h.mod.ban(username, reason); err != nil {
+			return err
+		}
+		h.kick(username, routing.AdminBan, reason)
+	case "unban":
+		if err := h.mod.unban(username); err != nil {
+			return err
+		}
+		fmt.Printf("%s is no longer banned.\n", username)
+	case "mute":
+		if len(words) < 3 {
+			return errors.New("usage: mute <username> <duration> [reason]")
+		}
+		duration, err := time.ParseDuration(words[2])
+		if err != nil || duration <= 0 {
+			return fmt.Errorf("%s is not a valid duration", words[2])
+		}
+		until := time.Now().Add(duration)
+		h.mod.mute(username, until)
+		h.broadcastAdmin(routing.AdminEvent{
+			Action:   routing.AdminMute,
+			Username: username,
+			Reason:   orDefault(strings.Join(words[3:], " "), "muted by an admin"),
+			Until:    until,
+		})
+	case "unmute":
+		h.mod.unmute(username)
+		h.broadcastAdmin(routing.AdminEvent{
+			Action:   routing.AdminUnmute,
+			Username: username,
+		})
+	default:
+		return fmt.Errorf("unknown moderation command %s", words[0])
+	}
+	return nil
+}
+
+func orDefault(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
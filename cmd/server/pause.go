@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+// pauseGames returns the games a pause target reaches: every game for
+// routing.PauseTargetAll, the game itself for a game ID, or each game the
+// player is online in for a username.
+func (h *gameHost) pauseGames(target string) []*game {
+	if target == routing.PauseTargetAll {
+		return h.list()
+	}
+	if g, ok := h.get(target); ok {
+		return []*game{g}
+	}
+	games := []*game{}
+	for _, g := range h.list() {
+		for _, record := range g.presence.players() {
+			if record.username == target {
+				games = append(games, g)
+				break
+			}
+		}
+	}
+	return games
+}
+
+// setPaused pauses or resumes target. A pause with a duration resumes by
+// itself when the duration runs out, and any earlier timer for the same
+// target is cancelled.
+func (h *gameHost) setPaused(target string, paused bool, reason string, duration time.Duration) error {
+	games := h.pauseGames(target)
+	if len(games) == 0 {
+		return fmt.Errorf("there is no game or online player called %s", target)
+	}
+
+	h.mu.Lock()
+	if timer, ok := h.resumeTimers[target]; ok {
+		timer.Stop()
+		delete(h.resumeTimers, target)
+	}
+	if target == routing.PauseTargetAll && !paused {
+		// Resuming everyone ends the game pauses; players paused on their
+		// own stay paused until their own resume.
+		for t, timer := range h.resumeTimers {
+			if _, isGame := h.games[t]; isGame || t == routing.PauseTargetAll {
+				timer.Stop()
+				delete(h.resumeTimers, t)
+			}
+		}
+	}
+	ps := routing.PlayingState{
+		IsPaused: paused,
+		Target:   target,
+		Reason:   reason,
+	}
+	if paused && duration > 0 {
+		ps.ResumeAt = time.Now().Add(duration)
+		h.resumeTimers[target] = time.AfterFunc(duration, func() {
+			defer fmt.Print("> ")
+			log.Printf("Pause on %s ran out, resuming.", target)
+			if err := h.setPaused(target, false, "", 0); err != nil {
+				log.Println("Trouble resuming: ", err)
+			}
+		})
+	}
+	h.mu.Unlock()
+
+	for _, g := range games {
+		if err := g.setPaused(ps); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commandPause handles "pause [all|<game>|<username>] [duration] [reason]"
+// and "resume [all|<game>|<username>]". Without a target it applies to the
+// game in use.
+func commandPause(h *gameHost, current *game, words []string) error {
+	paused := words[0] == "pause"
+	args := words[1:]
+	target := current.id
+	if len(args) > 0 && (args[0] == routing.PauseTargetAll || len(h.pauseGames(args[0])) > 0) {
+		target = args[0]
+		args = args[1:]
+	}
+	if !paused {
+		log.Printf("Sending resume message to %s.", target)
+		return h.setPaused(target, false, "", 0)
+	}
+
+	var duration time.Duration
+	if len(args) > 0 {
+		if d, err := time.ParseDuration(args[0]); err == nil {
+			if d <= 0 {
+				return fmt.Errorf("pause duration must be positive")
+			}
+			duration = d
+			args = args[1:]
+		}
+	}
+	reason := strings.Join(args, " ")
+	if duration > 0 {
+		log.Printf("Sending pause message to %s for %v.", target, duration)
+	} else {
+		log.Printf("Sending pause message to %s.", target)
+	}
+	return h.setPaused(target, true, reason, duration)
+}
+
+func (g *game) setPaused(ps routing.PlayingState) error {
+	g.mu.Lock()
+	// Pausing a single player leaves the rest of the game running.
+	if ps.Target == routing.PauseTargetAll || ps.Target == g.id {
+		g.pause = routing.PlayingState{}
+		if ps.IsPaused {
+			g.pause = ps
+		}
+	} else if ps.IsPaused {
+		g.playerPauses[ps.Target] = ps
+	} else {
+		delete(g.playerPauses, ps.Target)
+	}
+	g.mu.Unlock()
+	return pubsub.PublishJSON(g.channel, routing.ExchangePerilDirect, routing.GameKey(g.id, routing.PauseKey), ps)
+}
+
+// pausesFor returns the pauses a player joining the game is under: the
+// game's own and any on the player alone.
+func (g *game) pausesFor(username string) []routing.PlayingState {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	pauses := []routing.PlayingState{}
+	if g.pause.IsPaused {
+		pauses = append(pauses, g.pause)
+	}
+	if ps, ok := g.playerPauses[username]; ok {
+		pauses = append(pauses, ps)
+	}
+	return pauses
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// presenceTimeout is how long a player may stay silent before the server
+// assumes their client is gone.
+const presenceTimeout = 3 * routing.HeartbeatInterval
+
+type presenceRecord struct {
+	username string
+	joinedAt time.Time
+	lastSeen time.Time
+}
+
+// presence tracks which players are connected to a game.
+type presence struct {
+	mu      sync.Mutex
+	gameID  string
+	channel *amqp.Channel
+	online  map[string]*presenceRecord
+}
+
+func newPresence(gameID string, channel *amqp.Channel) *presence {
+	return &presence{
+		gameID:  gameID,
+		channel: channel,
+		online:  map[string]*presenceRecord{},
+	}
+}
+
+// handle applies a client's join, heartbeat or leave and reports whether it
+// changed who is online.
+func (p *presence) handle(msg routing.Presence) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	record, ok := p.online[msg.Username]
+	switch msg.Status {
+	case routing.PresenceJoin, routing.PresenceHeartbeat:
+		if ok {
+			record.lastSeen = now
+			return false
+		}
+		p.online[msg.Username] = &presenceRecord{
+			username: msg.Username,
+			joinedAt: now,
+			lastSeen: now,
+		}
+		p.broadcast(msg.Username, routing.PresenceJoin)
+		return true
+	case routing.PresenceLeave:
+		if !ok {
+			return false
+		}
+		delete(p.online, msg.Username)
+		p.broadcast(msg.Username, routing.PresenceLeave)
+		return true
+	}
+	return false
+}
+
+// remove takes a player offline without waiting for them to leave, for
+// example when they are kicked.
+func (p *presence) remove(username string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.online[username]; !ok {
+		return false
+	}
+	delete(p.online, username)
+	p.broadcast(username, routing.PresenceLeave)
+	return true
+}
+
+// sweep drops every player whose last heartbeat is older than the timeout.
+func (p *presence) sweep() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	cutoff := time.Now().Add(-presenceTimeout)
+	dropped := false
+	for username, record := range p.online {
+		if record.lastSeen.Before(cutoff) {
+			delete(p.online, username)
+			p.broadcast(username, routing.PresenceDrop)
+			dropped = true
+		}
+	}
+	if dropped {
+		fmt.Print("> ")
+	}
+}
+
+func (p *presence) watch() {
+	ticker := time.NewTicker(routing.HeartbeatInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		p.sweep()
+	}
+}
+
+// broadcast tells every player about a join, leave or drop. The caller must
+// hold p.mu.
+func (p *presence) broadcast(username, status string) {
+	log.Printf("%s: %s %s.", p.gameID, username, presenceVerb(status))
+	err := pubsub.PublishJSON(p.channel, routing.ExchangePerilDirect, routing.GameKey(p.gameID, routing.PresenceKey), routing.Presence{
+		Username: username,
+		Status:   status,
+		At:       time.Now(),
+	})
+	if err != nil {
+		log.Printf("Error publishing presence: %v", err)
+	}
+}
+
+func (p *presence) players() []presenceRecord {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	records := []presenceRecord{}
+	for _, record := range p.online {
+		records = append(records, *record)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].username < records[j].username })
+	return records
+}
+
+// usernames lists the players who are online.
+func (p *presence) usernames() []string {
+	usernames := []string{}
+	for _, record := range p.players() {
+		usernames = append(usernames, record.username)
+	}
+	return usernames
+}
+
+func presenceVerb(status string) string {
+	switch status {
+	case routing.PresenceJoin:
+		return "joined"
+	case routing.PresenceLeave:
+		return "left"
+	case routing.PresenceDrop:
+		return "dropped out"
+	}
+	return status
+}
+
+// handlerPresence takes the player from the routing key, so a client can only
+// report itself as joining, alive or leaving.
+func handlerPresence(p *presence) func(string, routing.Presence) pubsub.AckType {
+	return func(key string, msg routing.Presence) pubsub.AckType {
+		msg.Username = usernameFromKey(key)
+		if p.handle(msg) {
+			fmt.Print("> ")
+		}
+		return pubsub.Ack
+	}
+}
+
+func commandPlayers(g *game) {
+	records := g.presence.players()
+	if len(records) == 0 {
+		fmt.Printf("Nobody is connected to game %s.\n", g.id)
+		return
+	}
+	fmt.Printf("Players in game %s:\n", g.id)
+	for _, record := range records {
+		units := "unknown"
+		if count, ok := g.world.unitCount(record.username); ok {
+			units = fmt.Sprint(count)
+		}
+		fmt.Printf("* %s: %s units, last seen %v ago\n", record.username, units, time.Since(record.lastSeen).Round(time.Second))
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+// scheduleFile lists jobs to schedule when the server starts, for example
+//
+//	[{"Game": "default", "In": "2h", "Command": "endgame"},
+//	 {"Game": "default", "At": "18:00", "Command": "pause all 10m maintenance"}]
+//
+// The server rewrites the file with the jobs still pending, each at the
+// time it was set for, so a restart neither repeats a job that already ran
+// nor pushes an "In" job back.
+const scheduleFile = "schedule.json"
+
+// scheduleEntry is one job in the schedule file. Exactly one of In and At
+// is set, with the same syntax as the schedule command.
+type scheduleEntry struct {
+	Game    string
+	In      string `json:",omitempty"`
+	At      string `json:",omitempty"`
+	Command string
+}
+
+// scheduledJob runs a server command against a game at a set time.
+type scheduledJob struct {
+	id      int
+	gameID  string
+	command []string
+	at      time.Time
+	timer   *time.Timer
+}
+
+type scheduler struct {
+	mu     sync.Mutex
+	host   *gameHost
+	path   string
+	nextID int
+	jobs   map[int]*scheduledJob
+}
+
+// loadScheduler schedules every job in the schedule file. A missing file is
+// not an error. Jobs whose time passed while the server was down are
+// dropped, and jobs may name games that nobody has created yet.
+func loadScheduler(host *gameHost, path string) (*scheduler, error) {
+	s := &scheduler{
+		host: host,
+		path: path,
+		jobs: map[int]*scheduledJob{},
+	}
+	entries := []scheduleEntry{}
+	err := gamelogic.ReadSnapshotFile(path, &entries)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i, entry := range entries {
+		when, value := "in", entry.In
+		if entry.At != "" {
+			when, value = "at", entry.At
+		}
+		at, err := parseWhen(when, value)
+		if errors.Is(err, errTimePassed) {
+			log.Printf("Dropping job %d in %s, it was due at %s.", i+1, path, value)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("job %d in %s: %v", i+1, path, err)
+		}
+		job, err := s.schedule(entry.Game, strings.Fields(entry.Command), at)
+		if err != nil {
+			return nil, fmt.Errorf("job %d in %s: %v", i+1, path, err)
+		}
+		log.Printf("Scheduled job %d: %s.", job.id, job)
+	}
+	return s, s.save()
+}
+
+// add schedules command to run against the game at the given time.
+func (s *scheduler) add(gameID string, command []string, at time.Time) (*scheduledJob, error) {
+	if _, ok := s.host.get(gameID); !ok {
+		return nil, fmt.Errorf("there is no game called %s", gameID)
+	}
+	job, err := s.schedule(gameID, command, at)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.save(); err != nil {
+		log.Printf("Could not save the schedule: %v", err)
+	}
+	return job, nil
+}
+
+// schedule arms a job without checking that its game exists yet. The game
+// is looked up when the job runs.
+func (s *scheduler) schedule(gameID string, command []string, at time.Time) (*scheduledJob, error) {
+	if !routing.IsValidGameID(gameID) {
+		return nil, fmt.Errorf("%q is not a valid game ID", gameID)
+	}
+	if err := validateJob(command); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	job := &scheduledJob{
+		id:      s.nextID,
+		gameID:  gameID,
+		command: command,
+		at:      at,
+	}
+	job.timer = time.AfterFunc(time.Until(at), func() { s.run(job) })
+	s.jobs[job.id] = job
+	return job, nil
+}
+
+// save writes the pending jobs back to the schedule file.
+func (s *scheduler) save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := []scheduleEntry{}
+	for _, job := range s.pending() {
+		entries = append(entries, scheduleEntry{
+			Game:    job.gameID,
+			At:      job.at.Format(time.DateTime),
+			Command: strings.Join(job.command, " "),
+		})
+	}
+	return gamelogic.WriteSnapshotFile(s.path, entries)
+}
+
+func (s *scheduler) cancel(id int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return false
+	}
+	job.timer.Stop()
+	delete(s.jobs, id)
+	return true
+}
+
+func (s *scheduler) list() []*scheduledJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pending()
+}
+
+// pending returns the jobs in the order they run. The caller holds s.mu.
+func (s *scheduler) pending() []*scheduledJob {
+	jobs := []*scheduledJob{}
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].at.Before(jobs[j].at) })
+	return jobs
+}
+
+func (s *scheduler) run(job *scheduledJob) {
+	defer fmt.Print("> ")
+	s.mu.Lock()
+	delete(s.jobs, job.id)
+	s.mu.Unlock()
+	if err := s.save(); err != nil {
+		log.Printf("Could not save the schedule: %v", err)
+	}
+
+	g, ok := s.host.get(job.gameID)
+	if !ok {
+		log.Printf("Skipping job %d, there is no game called %s.", job.id, job.gameID)
+		return
+	}
+	log.Printf("Running job %d: %s.", job.id, job)
+	var err error
+	switch job.command[0] {
+	case "pause", "resume":
+		err = commandPause(s.host, g, job.command)
+	case "announce":
+		err = commandAnnounce(g, job.command)
+	case "endgame":
+		g.ref.endByScore("the game reached its scheduled end")
+	}
+	if err != nil {
+		log.Printf("Trouble with job %d: %v", job.id, err)
+	}
+}
+
+func (job *scheduledJob) String() string {
+	return fmt.Sprintf("%s on game %s at %s", strings.Join(job.command, " "), job.gameID, job.at.Format(time.DateTime))
+}
+
+func validateJob(command []string) error {
+	if len(command) == 0 {
+		return errors.New("the job has no command")
+	}
+	switch command[0] {
+	case "pause", "resume", "endgame":
+		return nil
+	case "announce":
+		if len(command) < 2 || (command[1] == "to" && len(command) < 4) {
+			return errors.New("announce needs a message")
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s cannot be scheduled, use pause, resume, announce or endgame", command[0])
+	}
+}
+
+// errTimePassed is returned for a date and time that is already over.
+var errTimePassed = errors.New("that time has already passed")
+
+// parseWhen turns "in <duration>" or "at <time>" into an absolute time. A
+// clock time that has already passed today means tomorrow.
+func parseWhen(when, value string) (time.Time, error) {
+	now := time.Now()
+	switch when {
+	case "in":
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if d <= 0 {
+			return time.Time{}, errors.New("the delay must be positive")
+		}
+		return now.Add(d), nil
+	case "at":
+		if t, err := time.ParseInLocation(time.DateTime, value, time.Local); err == nil {
+			if !t.After(now) {
+				return time.Time{}, errTimePassed
+			}
+			return t, nil
+		}
+		clock, err := time.ParseInLocation("15:04", value, time.Local)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%q is not a time, use 15:04 or %q", value, time.DateTime)
+		}
+		t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, time.Local)
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	default:
+		return time.Time{}, fmt.Errorf("expected in or at, got %s", when)
+	}
+}
+
+// commandSchedule handles "schedule <command> <in|at> <when> [args...]",
+// "jobs" and "cancel <job>". Scheduled commands apply to the game in use.
+func commandSchedule(s *scheduler, current *game, words []string) error {
+	switch words[0] {
+	case "jobs":
+		jobs := s.list()
+		if len(jobs) == 0 {
+			fmt.Println("No jobs are scheduled.")
+			return nil
+		}
+		fmt.Println("Scheduled jobs:")
+		for _, job := range jobs {
+			fmt.Printf("* %d: %s (in %v)\n", job.id, job, time.Until(job.at).Round(time.Second))
+		}
+		return nil
+	case "cancel":
+		if len(words) < 2 {
+			return errors.New("usage: cancel <job>")
+		}
+		id, err := strconv.Atoi(words[1])
+		if err != nil {
+			return fmt.Errorf("%s is not a job number", words[1])
+		}
+		if !s.cancel(id) {
+			return fmt.Errorf("there is no job %d", id)
+		}
+		log.Printf("Cancelled job %d.", id)
+		return s.save()
+	}
+
+	if len(words) < 4 {
+		return errors.New("usage: schedule <pause|resume|announce|endgame> <in|at> <when> [args...]")
+	}
+	at, err := parseWhen(words[2], words[3])
+	if err != nil {
+		return err
+	}
+	command := append([]string{words[1]}, words[4:]...)
+	job, err := s.add(current.id, command, at)
+	if err != nil {
+		return err
+	}
+	log.Printf("Scheduled job %d: %s.", job.id, job)
+	return nil
+}
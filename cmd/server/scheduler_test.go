@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+)
+
+func TestLoadSchedulerKeepsJobsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), scheduleFile)
+	entries := []scheduleEntry{
+		{Game: "europe", In: "1h", Command: "endgame"},
+		{Game: "default", At: time.Now().Add(-time.Hour).Format(time.DateTime), Command: "endgame"},
+	}
+	if err := gamelogic.WriteSnapshotFile(path, entries); err != nil {
+		t.Fatal(err)
+	}
+	host := newGameHost(nil, nil, nil)
+
+	s, err := loadScheduler(host, path)
+	if err != nil {
+		t.Fatalf("a job for a game that does not exist yet stopped the server: %v", err)
+	}
+	jobs := s.list()
+	if len(jobs) != 1 || jobs[0].gameID != "europe" {
+		t.Fatalf("scheduled %v, want only the europe job", jobs)
+	}
+	at := jobs[0].at.Truncate(time.Second)
+	// Stop the first server's timer without touching the file, as if it
+	// had been shut down.
+	s.cancel(jobs[0].id)
+
+	restarted, err := loadScheduler(host, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs = restarted.list()
+	if len(jobs) != 1 || !jobs[0].at.Equal(at) {
+		t.Fatalf("after a restart the job runs at %v, want %v", jobs, at)
+	}
+
+	if err := commandSchedule(restarted, nil, []string{"cancel", "1"}); err != nil {
+		t.Fatal(err)
+	}
+	saved := []scheduleEntry{}
+	if err := gamelogic.ReadSnapshotFile(path, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 0 {
+		t.Errorf("cancelled jobs are still saved: %+v", saved)
+	}
+}
+
+func TestLoadSchedulerRejectsBadJobs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), scheduleFile)
+	if err := os.WriteFile(path, []byte(`[{"Game": "default", "In": "1h", "Command": "shutdown"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadScheduler(newGameHost(nil, nil, nil), path); err == nil {
+		t.Error("loadScheduler() accepted a command that cannot be scheduled")
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+// A player signs everything with their own token, so the server must also
+// check that a message only speaks for the player who published it.
+func TestHandlersDropForgedSenders(t *testing.T) {
+	w := newWorld()
+	ref := newReferee(routing.DefaultGameID, nil, w)
+	turns := newTurnCoordinator(routing.DefaultGameID, nil, nil, nil, nil)
+	key := func(prefix string) string {
+		return routing.GameKey(routing.DefaultGameID, prefix) + ".mallory"
+	}
+
+	tests := []struct {
+		name    string
+		publish func() pubsub.AckType
+	}{
+		{"territory taken for someone else", func() pubsub.AckType {
+			return handlerTerritory(ref)(key(routing.TerritoryPrefix), gamelogic.TerritoryChange{Location: "asia", Owner: "alice"})
+		}},
+		{"territory given up for someone else", func() pubsub.AckType {
+			return handlerTerritory(ref)(key(routing.TerritoryPrefix), gamelogic.TerritoryChange{Location: "asia", PreviousOwner: "alice"})
+		}},
+		{"someone else's army", func() pubsub.AckType {
+			return handlerArmyState(w)(key(routing.ArmyStatePrefix), gamelogic.Player{Username: "alice"})
+		}},
+		{"someone else's turn", func() pubsub.AckType {
+			return handlerTurnEnd(turns)(key(routing.TurnEndPrefix), routing.TurnEnd{Username: "alice"})
+		}},
+		{"someone else's move", func() pubsub.AckType {
+			return handlerArmyMove(routing.DefaultGameID, w, nil)(key(routing.ArmyMovesPrefix), gamelogic.ArmyMove{Player: gamelogic.Player{Username: "alice"}})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ack := tt.publish(); ack != pubsub.NackDiscard {
+				t.Errorf("forged message got %v, want it discarded", ack)
+			}
+		})
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.territories) != 0 || len(w.armies) != 0 {
+		t.Errorf("forged messages changed the world: territories %v, armies %v", w.territories, w.armies)
+	}
+}
+
+func TestHandlersAcceptOwnMessages(t *testing.T) {
+	w := newWorld()
+	ref := newReferee(routing.DefaultGameID, nil, w)
+	key := func(prefix string) string {
+		return routing.GameKey(routing.DefaultGameID, prefix) + ".alice"
+	}
+
+	if ack := handlerTerritory(ref)(key(routing.TerritoryPrefix), gamelogic.TerritoryChange{Location: "asia", Owner: "alice"}); ack != pubsub.Ack {
+		t.Errorf("alice taking asia got %v", ack)
+	}
+	if ack := handlerArmyState(w)(key(routing.ArmyStatePrefix), gamelogic.Player{Username: "alice"}); ack != pubsub.Ack {
+		t.Errorf("alice's army got %v", ack)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.territories["asia"] != "alice" {
+		t.Errorf("asia belongs to %q, want alice", w.territories["asia"])
+	}
+	if _, ok := w.armies["alice"]; !ok {
+		t.Error("alice's army was not recorded")
+	}
+}
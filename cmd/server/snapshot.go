@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+)
+
+const serverSnapshotVersion = 1
+
+// serverSnapshotPath is where a hosted game is saved, one file per game.
+func serverSnapshotPath(gameID string) string {
+	return filepath.Join(gamelogic.SaveDir, "games", gameID+".json")
+}
+
+type serverSnapshot struct {
+	Version         int
+	SavedAt         time.Time
+	GameNumber      int
+	Territories     map[gamelogic.Location]string
+	Eliminated      map[string]bool
+	Armies          map[string]gamelogic.Player
+	Eliminate       bool
+	HoldTerritories int
+	HoldRounds      int
+	TimeLimit       time.Duration
+	Over            bool
+}
+
+func (w *world) snapshot(s *serverSnapshot) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	s.Territories = map[gamelogic.Location]string{}
+	for k, v := range w.territories {
+		s.Territories[k] = v
+	}
+	s.Eliminated = map[string]bool{}
+	for k, v := range w.players {
+		s.Eliminated[k] = v.eliminated
+	}
+	s.Armies = map[string]gamelogic.Player{}
+	for k, v := range w.armies {
+		s.Armies[k] = v
+	}
+}
+
+func (w *world) restore(s serverSnapshot) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.territories = map[gamelogic.Location]string{}
+	for k, v := range s.Territories {
+		w.territories[k] = v
+	}
+	w.players = map[string]*playerRecord{}
+	for k, v := range s.Eliminated {
+		w.players[k] = &playerRecord{username: k, eliminated: v}
+	}
+	w.armies = map[string]gamelogic.Player{}
+	for k, v := range s.Armies {
+		w.armies[k] = v
+	}
+}
+
+func (r *referee) save(path string) error {
+	r.mu.Lock()
+	s := serverSnapshot{
+		Version:         serverSnapshotVersion,
+		SavedAt:         time.Now(),
+		GameNumber:      r.gameNumber,
+		Eliminate:       r.config.eliminate,
+		HoldTerritories: r.config.holdTerritories,
+		HoldRounds:      r.config.holdRounds,
+		TimeLimit:       r.config.timeLimit,
+		Over:            r.over,
+	}
+	r.mu.Unlock()
+	r.world.snapshot(&s)
+	return gamelogic.WriteSnapshotFile(path, s)
+}
+
+func (r *referee) load(path string) error {
+	var s serverSnapshot
+	if err := gamelogic.ReadSnapshotFile(path, &s); err != nil {
+		return err
+	}
+	if s.Version > serverSnapshotVersion {
+		return fmt.Errorf("snapshot version %d is newer than the supported version %d", s.Version, serverSnapshotVersion)
+	}
+	r.world.restore(s)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.gameNumber = s.GameNumber
+	r.over = s.Over
+	r.config = victoryConfig{
+		eliminate:       s.Eliminate,
+		holdTerritories: s.HoldTerritories,
+		holdRounds:      s.HoldRounds,
+		timeLimit:       s.TimeLimit,
+	}
+	r.holder = ""
+	r.heldRounds = 0
+	r.armTimer()
+	return nil
+}
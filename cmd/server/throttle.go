@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/ratelimit"
+)
+
+// Every game log takes a second to write, so players get a small burst and
+// then one log every two seconds. Moves are cheap but fan out to everyone.
+const (
+	gameLogRate  = 0.5
+	gameLogBurst = 5
+	moveRate     = 2
+	moveBurst    = 10
+)
+
+// usernameFromKey returns the player a message came from. Players publish
+// to keys ending in their username, like default.game_logs.alice.
+func usernameFromKey(key string) string {
+	return key[strings.LastIndex(key, ".")+1:]
+}
+
+// throttled wraps a handler with a token bucket per player. Messages over
+// the limit are discarded, which dead-letters them, and the player is
+// flagged to the admin.
+func throttled[T any](limiter *ratelimit.Limiter, mod *moderator, what string, handler func(string, T) pubsub.AckType) func(string, T) pubsub.AckType {
+	return func(key string, val T) pubsub.AckType {
+		username := usernameFromKey(key)
+		if !limiter.Allow(username) {
+			if mod.flag(username, what) {
+				log.Printf("Flagged %s for flooding %s, dropping the excess.", username, what)
+				fmt.Print("> ")
+			}
+			return pubsub.NackDiscard
+		}
+		return handler(key, val)
+	}
+}
+
+// moderated wraps a handler so that the server ignores everything a banned
+// or recently kicked player publishes. A kick only closes the player's
+// client, so without this a modified client could carry on playing.
+func moderated[T any](mod *moderator, handler func(string, T) pubsub.AckType) func(string, T) pubsub.AckType {
+	return func(key string, val T) pubsub.AckType {
+		if mod.silenced(usernameFromKey(key)) {
+			return pubsub.NackDiscard
+		}
+		return handler(key, val)
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	turnModeSequential   = "sequential"
+	turnModeSimultaneous = "simultaneous"
+)
+
+var turnPhases = []string{routing.PhaseReinforce, routing.PhaseMove, routing.PhaseCombat}
+
+// turnCoordinator drives the optional turn mode. It publishes a TurnState
+// every time the phase changes and advances automatically on timeout.
+type turnCoordinator struct {
+	mu          sync.Mutex
+	publish     func(routing.TurnState) error
+	mode        string
+	players     []string
+	phaseLength time.Duration
+	state       routing.TurnState
+	playerIndex int
+	phaseIndex  int
+	ended       map[string]bool
+	timer       *time.Timer
+
+	// online lists the players in the game. In simultaneous mode without a
+	// player list, a phase ends early once all of them have ended it.
+	online     func() []string
+	onNewRound func(round int)
+	// onTurn is told about every turn state the coordinator publishes.
+	onTurn func(routing.TurnState)
+}
+
+func newTurnCoordinator(gameID string, channel *amqp.Channel, online func() []string, onNewRound func(round int), onTurn func(routing.TurnState)) *turnCoordinator {
+	return &turnCoordinator{
+		publish: func(ts routing.TurnState) error {
+			return pubsub.PublishJSON(channel, routing.ExchangePerilDirect, routing.GameKey(gameID, routing.TurnKey), ts)
+		},
+		ended:      map[string]bool{},
+		online:     online,
+		onNewRound: onNewRound,
+		onTurn:     onTurn,
+	}
+}
+
+func (tc *turnCoordinator) start(mode string, phaseLength time.Duration, players []string) error {
+	if mode != turnModeSequential && mode != turnModeSimultaneous {
+		return fmt.Errorf("unknown turn mode %s", mode)
+	}
+	if mode == turnModeSequential && len(players) == 0 {
+		return fmt.Errorf("sequential turns need at least one player")
+	}
+	if phaseLength <= 0 {
+		return fmt.Errorf("phase duration must be positive")
+	}
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.mode = mode
+	tc.players = players
+	tc.phaseLength = phaseLength
+	tc.playerIndex = 0
+	tc.phaseIndex = 0
+	tc.state = routing.TurnState{
+		Enabled: true,
+		Round:   1,
+	}
+	return tc.beginPhase()
+}
+
+// restart begins again from round one if turn mode is enabled.
+func (tc *turnCoordinator) restart() error {
+	tc.mu.Lock()
+	enabled, mode, phaseLength, players := tc.state.Enabled, tc.mode, tc.phaseLength, tc.players
+	tc.mu.Unlock()
+	if !enabled {
+		return nil
+	}
+	return tc.start(mode, phaseLength, players)
+}
+
+func (tc *turnCoordinator) enabled() bool {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return tc.state.Enabled
+}
+
+func (tc *turnCoordinator) stop() error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if tc.timer != nil {
+		tc.timer.Stop()
+	}
+	tc.state = routing.TurnState{}
+	return tc.announce()
+}
+
+func (tc *turnCoordinator) skip() error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if !tc.state.Enabled {
+		return fmt.Errorf("turn mode is not enabled")
+	}
+	return tc.advance()
+}
+
+func (tc *turnCoordinator) handleTurnEnd(te routing.TurnEnd) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if !tc.state.Enabled || te.Round != tc.state.Round || te.Phase != tc.state.Phase {
+		return
+	}
+
+	if tc.mode == turnModeSequential {
+		if te.Username != tc.state.ActivePlayer {
+			return
+		}
+		log.Printf("%s ended their %s phase.", te.Username, te.Phase)
+		tc.logAdvance(tc.advance())
+		return
+	}
+
+	tc.ended[te.Username] = true
+	players := tc.players
+	if len(players) == 0 {
+		players = tc.online()
+	}
+	for _, player := range players {
+		if !tc.ended[player] {
+			return
+		}
+	}
+	log.Printf("All players ended the %s phase.", te.Phase)
+	tc.logAdvance(tc.advance())
+}
+
+// advance moves to the next phase, the next player or the next round.
+// The caller must hold tc.mu.
+func (tc *turnCoordinator) advance() error {
+	tc.phaseIndex++
+	if tc.phaseIndex >= len(turnPhases) {
+		tc.phaseIndex = 0
+		if tc.mode == turnModeSequential {
+			tc.playerIndex++
+		}
+		if tc.mode == turnModeSimultaneous || tc.playerIndex >= len(tc.players) {
+			tc.playerIndex = 0
+			tc.state.Round++
+			if tc.onNewRound != nil {
+				tc.onNewRound(tc.state.Round)
+			}
+		}
+	}
+	return tc.beginPhase()
+}
+
+// beginPhase publishes the current phase and arms its deadline. The caller
+// must hold tc.mu.
+func (tc *turnCoordinator) beginPhase() error {
+	if tc.timer != nil {
+		tc.timer.Stop()
+	}
+	tc.ended = map[string]bool{}
+	tc.state.Phase = turnPhases[tc.phaseIndex]
+	tc.state.ActivePlayer = ""
+	if tc.mode == turnModeSequential {
+		tc.state.ActivePlayer = tc.players[tc.playerIndex]
+	}
+	tc.state.Deadline = time.Now().Add(tc.phaseLength)
+
+	round, phase, player := tc.state.Round, tc.state.Phase, tc.state.ActivePlayer
+	tc.timer = time.AfterFunc(tc.phaseLength, func() {
+		tc.mu.Lock()
+		defer tc.mu.Unlock()
+		if !tc.state.Enabled || tc.state.Round != round || tc.state.Phase != phase || tc.state.ActivePlayer != player {
+			return
+		}
+		log.Printf("Round %d %s phase timed out.", round, phase)
+		tc.logAdvance(tc.advance())
+	})
+	return tc.announce()
+}
+
+// announce publishes the turn state. The caller must hold tc.mu.
+func (tc *turnCoordinator) announce() error {
+	if tc.onTurn != nil {
+		tc.onTurn(tc.state)
+	}
+	return tc.publish(tc.state)
+}
+
+func (tc *turnCoordinator) logAdvance(err error) {
+	defer fmt.Print("> ")
+	if err != nil {
+		log.Printf("Error publishing turn state: %v", err)
+		return
+	}
+	log.Printf("Round %d: %s phase started.", tc.state.Round, tc.state.Phase)
+}
+
+func handlerTurnEnd(tc *turnCoordinator) func(string, routing.TurnEnd) pubsub.AckType {
+	return func(key string, te routing.TurnEnd) pubsub.AckType {
+		if te.Username != usernameFromKey(key) {
+			log.Printf("Dropping the end of %s's turn published by %s", te.Username, usernameFromKey(key))
+			return pubsub.NackDiscard
+		}
+		tc.handleTurnEnd(te)
+		return pubsub.Ack
+	}
+}
+
+func commandTurns(g *game, words []string) error {
+	tc := g.turns
+	if len(words) < 2 {
+		return fmt.Errorf("usage: turns <start|skip|stop>")
+	}
+	switch words[1] {
+	case "start":
+		if len(words) < 4 {
+			return fmt.Errorf("usage: turns start <sequential|simultaneous> <phase duration> [player] [player]...")
+		}
+		phaseLength, err := time.ParseDuration(words[3])
+		if err != nil {
+			return fmt.Errorf("%s is not a valid duration", words[3])
+		}
+		return tc.start(words[2], phaseLength, words[4:])
+	case "skip":
+		return tc.skip()
+	case "stop":
+		if g.ref.holdCondition() {
+			log.Println("The hold victory condition only counts turn rounds and is paused until turn mode starts again.")
+		}
+		return tc.stop()
+	}
+	return fmt.Errorf("unknown turns command %s", words[1])
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+func TestSimultaneousTurnsWithoutPlayerList(t *testing.T) {
+	online := []string{"alice", "bob"}
+	tc := newTurnCoordinator(routing.DefaultGameID, nil, func() []string { return online }, nil, nil)
+	published := []routing.TurnState{}
+	tc.publish = func(ts routing.TurnState) error {
+		published = append(published, ts)
+		return nil
+	}
+	if err := tc.start(turnModeSimultaneous, time.Hour, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer tc.stop()
+
+	end := func(username string) {
+		tc.handleTurnEnd(routing.TurnEnd{Username: username, Round: 1, Phase: routing.PhaseReinforce})
+	}
+	end("alice")
+	if phase := published[len(published)-1].Phase; phase != routing.PhaseReinforce {
+		t.Fatalf("the phase moved on to %s before bob ended it", phase)
+	}
+	end("bob")
+	if phase := published[len(published)-1].Phase; phase != routing.PhaseMove {
+		t.Errorf("phase is %s after everyone online ended it, want %s", phase, routing.PhaseMove)
+	}
+}
+
+func TestWarsWaitForCombatPhase(t *testing.T) {
+	w := newWorld()
+	w.recordArmy(army("alice", unitAt(1, gamelogic.RankInfantry, "asia")))
+	w.recordArmy(army("bob", unitAt(1, gamelogic.RankInfantry, "asia")))
+	w.recordArmy(army("carol", unitAt(1, gamelogic.RankInfantry, "europe")))
+	wf := newWarFront(routing.DefaultGameID, nil, w)
+	declared := []gamelogic.RecognitionOfWar{}
+	wf.publish = func(attacker string, rw gamelogic.RecognitionOfWar) error {
+		declared = append(declared, rw)
+		return nil
+	}
+	key := func(username string) string {
+		return routing.GameKey(routing.DefaultGameID, routing.WarRecognitionsPrefix) + "." + username
+	}
+
+	wf.setTurn(routing.TurnState{Enabled: true, Round: 1, Phase: routing.PhaseMove})
+	wf.handle(key("bob"), gamelogic.RecognitionOfWar{Attacker: gamelogic.Player{Username: "alice"}, Defender: gamelogic.Player{Username: "bob"}})
+	wf.handle(key("carol"), gamelogic.RecognitionOfWar{Attacker: gamelogic.Player{Username: "alice"}, Defender: gamelogic.Player{Username: "carol"}})
+	if len(declared) != 0 {
+		t.Fatalf("wars were declared in the move phase: %+v", declared)
+	}
+
+	wf.setTurn(routing.TurnState{Enabled: true, Round: 1, Phase: routing.PhaseCombat})
+	if len(declared) != 1 || declared[0].Defender.Username != "bob" {
+		t.Fatalf("the combat phase declared %+v, want only the war on bob", declared)
+	}
+	if len(declared[0].Attacker.Units) != 1 || len(declared[0].Defender.Units) != 1 {
+		t.Errorf("the war was declared without the armies: %+v", declared[0])
+	}
+
+	wf.handle(key("bob"), gamelogic.RecognitionOfWar{Attacker: gamelogic.Player{Username: "alice"}, Defender: gamelogic.Player{Username: "bob"}})
+	if len(declared) != 2 {
+		t.Error("a war recognised in the combat phase was held")
+	}
+}
+
+func army(username string, units ...gamelogic.Unit) gamelogic.Player {
+	p := gamelogic.Player{Username: username, Units: map[int]gamelogic.Unit{}}
+	for _, u := range units {
+		u.Owner = username
+		p.Units[u.ID] = u
+	}
+	return p
+}
+
+func unitAt(id int, rank gamelogic.UnitRank, loc gamelogic.Location) gamelogic.Unit {
+	return gamelogic.Unit{ID: id, Rank: rank, Location: loc}
+}
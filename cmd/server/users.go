@@ -0,0 +1,207 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const userStoreFile = "users.json"
+
+// reservationTTL is how long a username stays reserved after signing in,
+// giving the client time to join a game and start sending heartbeats.
+const reservationTTL = time.Minute
+
+type userRecord struct {
+	Username string
+	// Salt is only set on records whose password was hashed with salted
+	// SHA-256 before the server switched to bcrypt. They are rehashed the next
+	// time the player signs in with their password.
+	Salt         string `json:",omitempty"`
+	PasswordHash string
+	Token        string
+	CreatedAt    time.Time
+}
+
+// userStore keeps registered players on disk and reserves the usernames of
+// players who are signing in.
+type userStore struct {
+	mu       sync.Mutex
+	path     string
+	users    map[string]userRecord
+	reserved map[string]time.Time
+}
+
+func loadUserStore(path string) (*userStore, error) {
+	us := &userStore{
+		path:     path,
+		users:    map[string]userRecord{},
+		reserved: map[string]time.Time{},
+	}
+	err := gamelogic.ReadSnapshotFile(path, &us.users)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return us, nil
+}
+
+// authenticate checks the password or token, registering unknown usernames,
+// and reserves the username. online reports whether the player is already
+// connected to a game.
+func (us *userStore) authenticate(req routing.AuthRequest, online func(string) bool) (routing.AuthResponse, error) {
+	if req.Username == "" {
+		return routing.AuthResponse{}, errors.New("a username is required")
+	}
+	if !routing.IsValidUsername(req.Username) {
+		return routing.AuthResponse{}, fmt.Errorf("%q is not a valid username, use up to 32 letters, digits, - and _", req.Username)
+	}
+	if strings.EqualFold(req.Username, routing.ServerUsername) {
+		return routing.AuthResponse{}, fmt.Errorf("%s is reserved", req.Username)
+	}
+
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	if online(req.Username) || time.Now().Before(us.reserved[req.Username]) {
+		return routing.AuthResponse{}, fmt.Errorf("%s is already signed in", req.Username)
+	}
+
+	record, ok := us.users[req.Username]
+	registered := false
+	if !ok {
+		if len(req.Password) < 4 {
+			return routing.AuthResponse{}, errors.New("new players need a password of at least 4 characters")
+		}
+		var err error
+		record, err = newUserRecord(req.Username, req.Password)
+		if err != nil {
+			return routing.AuthResponse{}, err
+		}
+		us.users[req.Username] = record
+		if err := gamelogic.WriteSnapshotFile(us.path, us.users); err != nil {
+			delete(us.users, req.Username)
+			return routing.AuthResponse{}, err
+		}
+		registered = true
+	} else if !record.matches(req) {
+		return routing.AuthResponse{}, errors.New("wrong password or token")
+	} else if record.Salt != "" && req.Token == "" {
+		us.rehash(record, req.Password)
+	}
+
+	us.reserved[req.Username] = time.Now().Add(reservationTTL)
+	return routing.AuthResponse{
+		Token:      record.Token,
+		Registered: registered,
+	}, nil
+}
+
+// validToken reports whether token belongs to username, and releases the
+// reservation because the player is about to show up in a game.
+func (us *userStore) validToken(username, token string) bool {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	record, ok := us.users[username]
+	if !ok || !equalSecrets(record.Token, token) {
+		return false
+	}
+	delete(us.reserved, username)
+	return true
+}
+
+// rehash replaces a legacy SHA-256 password hash with a bcrypt one. The
+// player is signed in either way, so failures are only logged. Call it with
+// us.mu held.
+func (us *userStore) rehash(record userRecord, password string) {
+	hash, err := hashPassword(password)
+	if err != nil {
+		log.Printf("Trouble rehashing the password of %s: %v", record.Username, err)
+		return
+	}
+	record.Salt = ""
+	record.PasswordHash = hash
+	previous := us.users[record.Username]
+	us.users[record.Username] = record
+	if err := gamelogic.WriteSnapshotFile(us.path, us.users); err != nil {
+		us.users[record.Username] = previous
+		log.Printf("Trouble saving the rehashed password of %s: %v", record.Username, err)
+	}
+}
+
+// verify reports whether signature was made with the token of the player
+// who published to key. Unlike validToken it leaves the reservation alone.
+func (us *userStore) verify(key string, body []byte, signature string) bool {
+	us.mu.Lock()
+	record, ok := us.users[usernameFromKey(key)]
+	us.mu.Unlock()
+	return ok && pubsub.ValidSignature(record.Token, key, body, signature)
+}
+
+func newUserRecord(username, password string) (userRecord, error) {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return userRecord{}, err
+	}
+	token, err := randomHex(32)
+	if err != nil {
+		return userRecord{}, err
+	}
+	return userRecord{
+		Username:     username,
+		PasswordHash: hash,
+		Token:        token,
+		CreatedAt:    time.Now(),
+	}, nil
+}
+
+func (ur userRecord) matches(req routing.AuthRequest) bool {
+	if req.Token != "" {
+		return equalSecrets(ur.Token, req.Token)
+	}
+	if ur.Salt != "" {
+		return equalSecrets(ur.PasswordHash, legacyHash(ur.Salt, req.Password))
+	}
+	return bcrypt.CompareHashAndPassword([]byte(ur.PasswordHash), []byte(req.Password)) == nil
+}
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+		return "", errors.New("passwords may be at most 72 bytes long")
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not hash the password: %v", err)
+	}
+	return string(hash), nil
+}
+
+// legacyHash is how passwords were hashed before bcrypt, kept to check the
+// records that have not been rehashed yet.
+func legacyHash(salt, password string) string {
+	sum := sha256.Sum256([]byte(salt + password))
+	return hex.EncodeToString(sum[:])
+}
+
+func equalSecrets(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not generate a secret: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
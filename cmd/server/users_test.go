@@ -0,0 +1,87 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+func TestAuthenticate(t *testing.T) {
+	us, err := loadUserStore(filepath.Join(t.TempDir(), userStoreFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	offline := func(string) bool { return false }
+
+	resp, err := us.authenticate(routing.AuthRequest{Username: "alice", Password: "hunter2"}, offline)
+	if err != nil || !resp.Registered || resp.Token == "" {
+		t.Fatalf("registering alice = %+v, %v", resp, err)
+	}
+	delete(us.reserved, "alice")
+
+	tests := []struct {
+		name string
+		req  routing.AuthRequest
+		ok   bool
+	}{
+		{"right password", routing.AuthRequest{Username: "alice", Password: "hunter2"}, true},
+		{"wrong password", routing.AuthRequest{Username: "alice", Password: "hunter3"}, false},
+		{"token", routing.AuthRequest{Username: "alice", Token: resp.Token}, true},
+		{"wrong token", routing.AuthRequest{Username: "alice", Token: "nope"}, false},
+		{"short password for a new player", routing.AuthRequest{Username: "bob", Password: "abc"}, false},
+		{"reserved server name", routing.AuthRequest{Username: "server", Password: "hunter2"}, false},
+		{"reserved server name in capitals", routing.AuthRequest{Username: "Server", Password: "hunter2"}, false},
+		{"dotted name", routing.AuthRequest{Username: "bob.smith", Password: "hunter2"}, false},
+		{"single word wildcard", routing.AuthRequest{Username: "*", Password: "hunter2"}, false},
+		{"multi word wildcard", routing.AuthRequest{Username: "#", Password: "hunter2"}, false},
+		{"path", routing.AuthRequest{Username: "../bob", Password: "hunter2"}, false},
+		{"slash", routing.AuthRequest{Username: "bob/alice", Password: "hunter2"}, false},
+		{"space", routing.AuthRequest{Username: "bob smith", Password: "hunter2"}, false},
+		{"too long", routing.AuthRequest{Username: strings.Repeat("b", 33), Password: "hunter2"}, false},
+		{"dashes and underscores", routing.AuthRequest{Username: "bob-the_2nd", Password: "hunter2"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := us.authenticate(tt.req, offline)
+			if (err == nil) != tt.ok {
+				t.Errorf("authenticate(%+v) error = %v, want ok %v", tt.req, err, tt.ok)
+			}
+			delete(us.reserved, tt.req.Username)
+		})
+	}
+}
+
+func TestAuthenticateRehashesLegacyPasswords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), userStoreFile)
+	legacy := map[string]userRecord{
+		"alice": {Username: "alice", Salt: "pepper", PasswordHash: legacyHash("pepper", "hunter2"), Token: "t0ken"},
+	}
+	if err := gamelogic.WriteSnapshotFile(path, legacy); err != nil {
+		t.Fatal(err)
+	}
+	us, err := loadUserStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offline := func(string) bool { return false }
+
+	if _, err := us.authenticate(routing.AuthRequest{Username: "alice", Password: "hunter2"}, offline); err != nil {
+		t.Fatalf("legacy sign in failed: %v", err)
+	}
+	delete(us.reserved, "alice")
+
+	reloaded, err := loadUserStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := reloaded.users["alice"]
+	if record.Salt != "" || record.PasswordHash == legacyHash("pepper", "hunter2") {
+		t.Fatalf("alice's password was not rehashed: %+v", record)
+	}
+	if _, err := reloaded.authenticate(routing.AuthRequest{Username: "alice", Password: "hunter2"}, offline); err != nil {
+		t.Errorf("sign in after rehashing failed: %v", err)
+	}
+}
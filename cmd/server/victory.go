@@ -0,0 +1,291 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/pubsub"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+type victoryConfig struct {
+	eliminate       bool
+	holdTerritories int
+	holdRounds      int
+	timeLimit       time.Duration
+}
+
+func (vc victoryConfig) String() string {
+	str := fmt.Sprintf("eliminate all opponents: %v", vc.eliminate)
+	if vc.holdTerritories > 0 {
+		str += fmt.Sprintf(", hold %d territories for %d rounds", vc.holdTerritories, vc.holdRounds)
+	}
+	if vc.timeLimit > 0 {
+		str += fmt.Sprintf(", highest score after %v", vc.timeLimit)
+	}
+	return str
+}
+
+// referee watches the world for victory conditions and runs the
+// end-of-game flow.
+type referee struct {
+	mu         sync.Mutex
+	gameID     string
+	channel    *amqp.Channel
+	world      *world
+	config     victoryConfig
+	gameNumber int
+	over       bool
+	holder     string
+	heldRounds int
+	timer      *time.Timer
+}
+
+func newReferee(gameID string, channel *amqp.Channel, w *world) *referee {
+	r := &referee{
+		gameID:     gameID,
+		channel:    channel,
+		world:      w,
+		config:     victoryConfig{eliminate: true},
+		gameNumber: 1,
+	}
+	return r
+}
+
+func (r *referee) handleTerritoryChange(tc gamelogic.TerritoryChange) {
+	eliminated := r.world.applyTerritoryChange(tc)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.over {
+		return
+	}
+	for _, username := range eliminated {
+		log.Printf("%s has been eliminated.", username)
+		err := pubsub.PublishJSON(r.channel, routing.ExchangePerilDirect, routing.GameKey(r.gameID, routing.EliminatedKey), routing.PlayerEliminated{
+			Username: username,
+			Reason:   "you lost your last territory",
+		})
+		if err != nil {
+			log.Printf("Error publishing elimination: %v", err)
+		}
+	}
+
+	if !r.config.eliminate {
+		return
+	}
+	standings := r.world.standings()
+	if len(standings) < 2 {
+		return
+	}
+	survivors := []string{}
+	for _, standing := range standings {
+		if !standing.Eliminated {
+			survivors = append(survivors, standing.Username)
+		}
+	}
+	if len(survivors) == 1 {
+		r.finish(survivors[0], "all opponents have been eliminated")
+	}
+}
+
+// handleNewRound is called by the turn coordinator whenever a round starts.
+func (r *referee) handleNewRound(round int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.over || r.config.holdTerritories <= 0 {
+		return
+	}
+	leader := ""
+	for _, standing := range r.world.standings() {
+		if standing.Territories >= r.config.holdTerritories {
+			leader = standing.Username
+			break
+		}
+	}
+	if leader == "" || leader != r.holder {
+		r.holder = leader
+		r.heldRounds = 0
+	}
+	if leader == "" {
+		return
+	}
+	r.heldRounds++
+	log.Printf("%s has held %d territories for %d round(s).", leader, r.config.holdTerritories, r.heldRounds)
+	if r.heldRounds >= r.config.holdRounds {
+		r.finish(leader, fmt.Sprintf("held %d territories for %d rounds", r.config.holdTerritories, r.config.holdRounds))
+	}
+}
+
+func (r *referee) endByScore(reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.over {
+		return
+	}
+	standings := r.world.standings()
+	winner := ""
+	if len(standings) == 1 || len(standings) > 1 && standings[0].Territories > standings[1].Territories {
+		winner = standings[0].Username
+	}
+	r.finish(winner, reason)
+}
+
+// finish broadcasts the final standings. The caller must hold r.mu.
+func (r *referee) finish(winner, reason string) {
+	defer fmt.Print("> ")
+	r.over = true
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	standings := r.world.standings()
+	log.Printf("Game over: %s", reason)
+	gamelogic.PrintStandings(os.Stdout, standings)
+	err := pubsub.PublishJSON(r.channel, routing.ExchangePerilDirect, routing.GameKey(r.gameID, routing.GameOverKey), routing.GameOver{
+		Winner:    winner,
+		Reason:    reason,
+		Standings: standings,
+	})
+	if err != nil {
+		log.Printf("Error publishing game over: %v", err)
+	}
+}
+
+func (r *referee) setConfig(vc victoryConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.config = vc
+	r.holder = ""
+	r.heldRounds = 0
+	r.armTimer()
+}
+
+// number returns the number of the game being played, counting the games
+// started with newgame from one.
+func (r *referee) number() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.gameNumber
+}
+
+// armTimer starts the time limit clock. The caller must hold r.mu.
+func (r *referee) armTimer() {
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	if r.config.timeLimit <= 0 || r.over {
+		return
+	}
+	gameNumber := r.gameNumber
+	r.timer = time.AfterFunc(r.config.timeLimit, func() {
+		r.mu.Lock()
+		current := r.gameNumber
+		r.mu.Unlock()
+		if current == gameNumber {
+			r.endByScore("the time limit was reached")
+		}
+	})
+}
+
+func (r *referee) newGame(mode gamelogic.CombatMode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.world.reset()
+	r.gameNumber++
+	r.over = false
+	r.holder = ""
+	r.heldRounds = 0
+	r.armTimer()
+	return pubsub.PublishJSON(r.channel, routing.ExchangePerilDirect, routing.GameKey(r.gameID, routing.NewGameKey), routing.NewGame{
+		GameNumber: r.gameNumber,
+		StartedAt:  time.Now(),
+		CombatMode: string(mode),
+	})
+}
+
+// handlerTerritory applies a territory change. Players only publish their
+// own changes: the territories they take, or with no new owner, the ones
+// they lose.
+func handlerTerritory(r *referee) func(string, gamelogic.TerritoryChange) pubsub.AckType {
+	return func(key string, tc gamelogic.TerritoryChange) pubsub.AckType {
+		changedBy := tc.Owner
+		if changedBy == "" {
+			changedBy = tc.PreviousOwner
+		}
+		if changedBy != usernameFromKey(key) {
+			log.Printf("Dropping a change to %s by %s published by %s", tc.Location, changedBy, usernameFromKey(key))
+			return pubsub.NackDiscard
+		}
+		r.handleTerritoryChange(tc)
+		return pubsub.Ack
+	}
+}
+
+// holdCondition reports whether the game can be won by holding territory,
+// which is only checked when a turn round starts.
+func (r *referee) holdCondition() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.config.holdTerritories > 0
+}
+
+func commandVictory(g *game, words []string) error {
+	r := g.ref
+	r.mu.Lock()
+	vc := r.config
+	r.mu.Unlock()
+	if len(words) == 1 {
+		fmt.Printf("Victory conditions: %v\n", vc)
+		if vc.holdTerritories > 0 && !g.turns.enabled() {
+			fmt.Println("Turn mode is off, so nobody is earning rounds toward the hold condition.")
+		}
+		return nil
+	}
+	switch words[1] {
+	case "eliminate":
+		if len(words) != 3 || (words[2] != "on" && words[2] != "off") {
+			return fmt.Errorf("usage: victory eliminate <on|off>")
+		}
+		vc.eliminate = words[2] == "on"
+	case "hold":
+		if len(words) != 4 {
+			return fmt.Errorf("usage: victory hold <territories> <rounds>")
+		}
+		if !g.turns.enabled() {
+			return fmt.Errorf("holding territory is counted in turn rounds, start turn mode first")
+		}
+		territories, err := strconv.Atoi(words[2])
+		if err != nil || territories < 1 {
+			return fmt.Errorf("%s is not a valid number of territories", words[2])
+		}
+		rounds, err := strconv.Atoi(words[3])
+		if err != nil || rounds < 1 {
+			return fmt.Errorf("%s is not a valid number of rounds", words[3])
+		}
+		vc.holdTerritories = territories
+		vc.holdRounds = rounds
+	case "time":
+		if len(words) != 3 {
+			return fmt.Errorf("usage: victory time <duration>")
+		}
+		limit, err := time.ParseDuration(words[2])
+		if err != nil || limit <= 0 {
+			return fmt.Errorf("%s is not a valid duration", words[2])
+		}
+		vc.timeLimit = limit
+	case "off":
+		vc = victoryConfig{}
+	default:
+		return fmt.Errorf("unknown victory condition %s", words[1])
+	}
+	r.setConfig(vc)
+	fmt.Printf("Victory conditions: %v\n", vc)
+	return nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+type playerRecord struct {
+	username   string
+	eliminated bool
+}
+
+// world is the server's view of the game, built from the events clients
+// broadcast.
+type world struct {
+	mu          sync.Mutex
+	territories map[gamelogic.Location]string
+	players     map[string]*playerRecord
+	armies      map[string]gamelogic.Player
+}
+
+func newWorld() *world {
+	return &world{
+		territories: map[gamelogic.Location]string{},
+		players:     map[string]*playerRecord{},
+		armies:      map[string]gamelogic.Player{},
+	}
+}
+
+func (w *world) reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.territories = map[gamelogic.Location]string{}
+	w.players = map[string]*playerRecord{}
+	w.armies = map[string]gamelogic.Player{}
+}
+
+func (w *world) recordArmy(p gamelogic.Player) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.armies[p.Username] = p
+}
+
+// visibleMoves records the mover's army and returns the filtered move each
+// other player is able to see, keyed by username.
+func (w *world) visibleMoves(move gamelogic.ArmyMove) map[string]gamelogic.ArmyMove {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.armies[move.Player.Username] = move.Player
+	moves := map[string]gamelogic.ArmyMove{}
+	for username, army := range w.armies {
+		if username == move.Player.Username {
+			continue
+		}
+		if visible, ok := gamelogic.FilterMoveFor(move, army); ok {
+			moves[username] = visible
+		}
+	}
+	return moves
+}
+
+// visibleWarResults returns the part of a war each player other than the
+// attacker is able to see, keyed by username. The defender gets every battle.
+func (w *world) visibleWarResults(wr gamelogic.WarResult) map[string]gamelogic.WarResult {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	results := map[string]gamelogic.WarResult{wr.Defender: wr}
+	for username, army := range w.armies {
+		if username == wr.Attacker || username == wr.Defender {
+			continue
+		}
+		if visible, ok := gamelogic.FilterWarResultFor(wr, army); ok {
+			results[username] = visible
+		}
+	}
+	return results
+}
+
+// army returns a player's army as they last reported it.
+func (w *world) army(username string) (gamelogic.Player, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	army, ok := w.armies[username]
+	return army, ok
+}
+
+// unitCount reports how many units a player had when they last published
+// their army.
+func (w *world) unitCount(username string) (int, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	army, ok := w.armies[username]
+	return len(army.Units), ok
+}
+
+func (w *world) addPlayer(username string) {
+	if _, ok := w.players[username]; !ok {
+		w.players[username] = &playerRecord{username: username}
+	}
+}
+
+// applyTerritoryChange records the change and returns the players who were
+// eliminated by it.
+func (w *world) applyTerritoryChange(tc gamelogic.TerritoryChange) []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if tc.Owner == "" {
+		if w.territories[tc.Location] == tc.PreviousOwner {
+			delete(w.territories, tc.Location)
+		}
+	} else {
+		w.addPlayer(tc.Owner)
+		w.territories[tc.Location] = tc.Owner
+	}
+
+	counts := w.territoryCounts()
+	eliminated := []string{}
+	for _, player := range w.players {
+		if !player.eliminated && counts[player.username] == 0 {
+			player.eliminated = true
+			eliminated = append(eliminated, player.username)
+		}
+	}
+	sort.Strings(eliminated)
+	return eliminated
+}
+
+// territoryCounts must be called with w.mu held.
+func (w *world) territoryCounts() map[string]int {
+	counts := map[string]int{}
+	for _, owner := range w.territories {
+		counts[owner]++
+	}
+	return counts
+}
+
+func (w *world) standings() []routing.Standing {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	counts := w.territoryCounts()
+	standings := []routing.Standing{}
+	for _, player := range w.players {
+		standings = append(standings, routing.Standing{
+			Username:    player.username,
+			Territories: counts[player.username],
+			Eliminated:  player.eliminated,
+		})
+	}
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Territories != standings[j].Territories {
+			return standings[i].Territories > standings[j].Territories
+		}
+		return standings[i].Username < standings[j].Username
+	})
+	return standings
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/bot"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/sim"
+)
+
+func main() {
+	games := flag.Int("games", 1000, "number of games to simulate")
+	strategies := flag.String("strategies", "random,greedy,defensive", "comma separated strategies, one bot per entry")
+	difficultyName := flag.String("difficulty", string(bot.DifficultyHard), "easy, normal or hard")
+	rounds := flag.Int("rounds", 30, "maximum rounds per game")
+	mode := flag.String("mode", string(gamelogic.CombatModeProportional), "combat mode: proportional or dice")
+	seed := flag.Int64("seed", 1, "random seed, the same seed reproduces the same results")
+	flag.Parse()
+
+	difficulty, err := bot.ParseDifficulty(*difficultyName)
+	if err != nil {
+		log.Fatalf("Bad difficulty: %v", err)
+	}
+	if !gamelogic.IsValidCombatMode(*mode) {
+		log.Fatalf("Bad combat mode: %s", *mode)
+	}
+	catalogErr := gamelogic.LoadUnitCatalog(gamelogic.UnitCatalogFile)
+	if catalogErr != nil && !errors.Is(catalogErr, os.ErrNotExist) {
+		log.Fatalf("Trouble loading unit catalog: %v", catalogErr)
+	}
+
+	start := time.Now()
+	report, err := sim.Run(sim.Config{
+		Games:      *games,
+		Strategies: strings.Split(*strategies, ","),
+		Difficulty: difficulty,
+		Rounds:     *rounds,
+		Mode:       gamelogic.CombatMode(*mode),
+		Seed:       *seed,
+	})
+	if err != nil {
+		log.Fatalf("Simulation failed: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	report.Print(os.Stdout)
+	fmt.Printf("\nSimulated in %v (%.0f games per minute)\n", elapsed.Round(time.Millisecond), float64(*games)/elapsed.Minutes())
+}
@@ -0,0 +1,112 @@
+package bot
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+type Difficulty string
+
+const (
+	DifficultyEasy   Difficulty = "easy"
+	DifficultyNormal Difficulty = "normal"
+	DifficultyHard   Difficulty = "hard"
+)
+
+func ParseDifficulty(s string) (Difficulty, error) {
+	switch Difficulty(s) {
+	case DifficultyEasy, DifficultyNormal, DifficultyHard:
+		return Difficulty(s), nil
+	}
+	return "", fmt.Errorf("unknown difficulty %s", s)
+}
+
+// mistakeRate is the chance that a bot ignores its strategy and plays a
+// random command instead.
+func (d Difficulty) mistakeRate() float64 {
+	switch d {
+	case DifficultyEasy:
+		return 0.5
+	case DifficultyNormal:
+		return 0.2
+	}
+	return 0
+}
+
+// View is everything a strategy may look at when choosing a command.
+type View struct {
+	Username    string
+	Units       []gamelogic.Unit
+	Opponents   []gamelogic.Player
+	Territories map[gamelogic.Location]string
+	Treasury    int
+	Turn        routing.TurnState
+}
+
+func NewView(gs *gamelogic.GameState) View {
+	player := gs.GetPlayerSnap()
+	units := []gamelogic.Unit{}
+	for _, unit := range player.Units {
+		units = append(units, unit)
+	}
+	sort.Slice(units, func(i, j int) bool { return units[i].ID < units[j].ID })
+	return View{
+		Username:    player.Username,
+		Units:       units,
+		Opponents:   gs.GetOpponentsSnap(),
+		Territories: gs.GetTerritoriesSnap(),
+		Treasury:    gs.GetTreasury(),
+		Turn:        gs.GetTurn(),
+	}
+}
+
+// Strategy picks the next command for a bot, in the same words a player
+// would type, or nil to do nothing.
+type Strategy interface {
+	Name() string
+	Decide(v View, rng *rand.Rand) []string
+}
+
+func StrategyNames() []string {
+	return []string{"random", "greedy", "defensive"}
+}
+
+func NewStrategy(name string) (Strategy, error) {
+	switch name {
+	case "random":
+		return randomStrategy{}, nil
+	case "greedy":
+		return greedyStrategy{}, nil
+	case "defensive":
+		return defensiveStrategy{}, nil
+	}
+	return nil, fmt.Errorf("unknown strategy %s, expected one of %v", name, StrategyNames())
+}
+
+type Bot struct {
+	State      *gamelogic.GameState
+	Strategy   Strategy
+	Difficulty Difficulty
+	rng        *rand.Rand
+}
+
+func New(gs *gamelogic.GameState, strategy Strategy, difficulty Difficulty, seed int64) *Bot {
+	return &Bot{
+		State:      gs,
+		Strategy:   strategy,
+		Difficulty: difficulty,
+		rng:        rand.New(rand.NewSource(seed)),
+	}
+}
+
+func (b *Bot) Decide() []string {
+	v := NewView(b.State)
+	if b.rng.Float64() < b.Difficulty.mistakeRate() {
+		return randomStrategy{}.Decide(v, b.rng)
+	}
+	return b.Strategy.Decide(v, b.rng)
+}
@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"strconv"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+func canSpawn(v View) bool {
+	return !v.Turn.Enabled || v.Turn.Phase == routing.PhaseReinforce && v.isActive()
+}
+
+func canMove(v View) bool {
+	return !v.Turn.Enabled || v.Turn.Phase == routing.PhaseMove && v.isActive()
+}
+
+func (v View) isActive() bool {
+	return v.Turn.ActivePlayer == "" || v.Turn.ActivePlayer == v.Username
+}
+
+func affordableTypes(v View) []gamelogic.UnitType {
+	types := []gamelogic.UnitType{}
+	for _, ut := range gamelogic.GetUnitTypes() {
+		if ut.Cost <= v.Treasury {
+			types = append(types, ut)
+		}
+	}
+	return types
+}
+
+// spawnLocations returns the locations the player may spawn in: the ones
+// they own, or every unclaimed location before their first deployment.
+func spawnLocations(v View) []gamelogic.Location {
+	owned := []gamelogic.Location{}
+	unclaimed := []gamelogic.Location{}
+	for _, loc := range gamelogic.GetLocations() {
+		owner, ok := v.Territories[loc]
+		if owner == v.Username {
+			owned = append(owned, loc)
+		} else if !ok {
+			unclaimed = append(unclaimed, loc)
+		}
+	}
+	if len(owned) > 0 {
+		return owned
+	}
+	return unclaimed
+}
+
+func unitsAt(units []gamelogic.Unit, loc gamelogic.Location) []gamelogic.Unit {
+	found := []gamelogic.Unit{}
+	for _, unit := range units {
+		if unit.Location == loc {
+			found = append(found, unit)
+		}
+	}
+	return found
+}
+
+func enemyUnitsAt(v View, loc gamelogic.Location) []gamelogic.Unit {
+	found := []gamelogic.Unit{}
+	for _, opponent := range v.Opponents {
+		for _, unit := range opponent.Units {
+			if unit.Location == loc {
+				found = append(found, unit)
+			}
+		}
+	}
+	return found
+}
+
+// movableUnits returns the units at loc that can reach a neighbouring location.
+func movableUnits(v View, loc gamelogic.Location) []gamelogic.Unit {
+	movable := []gamelogic.Unit{}
+	for _, unit := range unitsAt(v.Units, loc) {
+		ut, ok := gamelogic.GetUnitType(unit.Rank)
+		if ok && (ut.Movement > 0 || ut.HasAbility(gamelogic.AbilityAmphibious)) {
+			movable = append(movable, unit)
+		}
+	}
+	return movable
+}
+
+func occupiedLocations(units []gamelogic.Unit) []gamelogic.Location {
+	seen := map[gamelogic.Location]bool{}
+	locations := []gamelogic.Location{}
+	for _, loc := range gamelogic.GetLocations() {
+		for _, unit := range units {
+			if unit.Location == loc && !seen[loc] {
+				seen[loc] = true
+				locations = append(locations, loc)
+			}
+		}
+	}
+	return locations
+}
+
+func spawnCommand(loc gamelogic.Location, rank gamelogic.UnitRank) []string {
+	return []string{"spawn", string(loc), string(rank)}
+}
+
+func moveCommand(loc gamelogic.Location, units []gamelogic.Unit) []string {
+	words := []string{"move", string(loc)}
+	for _, unit := range units {
+		words = append(words, strconv.Itoa(unit.ID))
+	}
+	return words
+}
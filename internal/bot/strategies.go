@@ -0,0 +1,179 @@
+package bot
+
+import (
+	"math/rand"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+)
+
+// randomStrategy spawns or moves at random.
+type randomStrategy struct{}
+
+func (randomStrategy) Name() string {
+	return "random"
+}
+
+func (randomStrategy) Decide(v View, rng *rand.Rand) []string {
+	spawn := func() []string {
+		types := affordableTypes(v)
+		locations := spawnLocations(v)
+		if !canSpawn(v) || len(types) == 0 || len(locations) == 0 {
+			return nil
+		}
+		return spawnCommand(locations[rng.Intn(len(locations))], types[rng.Intn(len(types))].Rank)
+	}
+	move := func() []string {
+		if !canMove(v) || len(v.Units) == 0 {
+			return nil
+		}
+		unit := v.Units[rng.Intn(len(v.Units))]
+		neighbors := gamelogic.GetNeighbors(unit.Location)
+		if len(movableUnits(v, unit.Location)) == 0 || len(neighbors) == 0 {
+			return nil
+		}
+		return moveCommand(neighbors[rng.Intn(len(neighbors))], []gamelogic.Unit{unit})
+	}
+
+	if rng.Intn(2) == 0 {
+		if words := spawn(); words != nil {
+			return words
+		}
+		return move()
+	}
+	if words := move(); words != nil {
+		return words
+	}
+	return spawn()
+}
+
+// greedyStrategy buys the strongest attackers it can afford and throws its
+// stacks at the most valuable location it can win.
+type greedyStrategy struct{}
+
+func (greedyStrategy) Name() string {
+	return "greedy"
+}
+
+func (greedyStrategy) Decide(v View, rng *rand.Rand) []string {
+	if canMove(v) {
+		if words := greedyMove(v); words != nil {
+			return words
+		}
+	}
+	if !canSpawn(v) {
+		return nil
+	}
+	types := affordableTypes(v)
+	locations := spawnLocations(v)
+	if len(types) == 0 || len(locations) == 0 {
+		return nil
+	}
+	best := types[0]
+	for _, ut := range types {
+		if ut.Attack > best.Attack {
+			best = ut
+		}
+	}
+	target := locations[0]
+	for _, loc := range locations {
+		if gamelogic.GetLocationIncome(loc) > gamelogic.GetLocationIncome(target) {
+			target = loc
+		}
+	}
+	return spawnCommand(target, best.Rank)
+}
+
+func greedyMove(v View) []string {
+	bestScore := 0
+	var best []string
+	for _, from := range occupiedLocations(v.Units) {
+		movable := movableUnits(v, from)
+		if len(movable) == 0 {
+			continue
+		}
+		attack := gamelogic.AttackPower(movable)
+		for _, to := range gamelogic.GetNeighbors(from) {
+			if v.Territories[to] == v.Username {
+				continue
+			}
+			score := gamelogic.GetLocationIncome(to)
+			if enemies := enemyUnitsAt(v, to); len(enemies) > 0 {
+				defense := gamelogic.DefensePower(enemies)
+				if attack <= defense {
+					continue
+				}
+				score += defense * 10
+			}
+			if score > bestScore {
+				bestScore = score
+				best = moveCommand(to, movable)
+			}
+		}
+	}
+	return best
+}
+
+// defensiveStrategy reinforces the territories under the greatest threat
+// and only expands into locations no enemy can reach.
+type defensiveStrategy struct{}
+
+func (defensiveStrategy) Name() string {
+	return "defensive"
+}
+
+func (defensiveStrategy) Decide(v View, rng *rand.Rand) []string {
+	if canSpawn(v) {
+		if words := defensiveSpawn(v); words != nil {
+			return words
+		}
+	}
+	if !canMove(v) {
+		return nil
+	}
+	for _, from := range occupiedLocations(v.Units) {
+		movable := movableUnits(v, from)
+		if len(movable) < 2 || threat(v, from) > 0 {
+			continue
+		}
+		for _, to := range gamelogic.GetNeighbors(from) {
+			if _, owned := v.Territories[to]; owned || threat(v, to) > 0 {
+				continue
+			}
+			return moveCommand(to, movable[:1])
+		}
+	}
+	return nil
+}
+
+func defensiveSpawn(v View) []string {
+	types := affordableTypes(v)
+	locations := spawnLocations(v)
+	if len(types) == 0 || len(locations) == 0 {
+		return nil
+	}
+	best := types[0]
+	for _, ut := range types {
+		if ut.Defense > best.Defense {
+			best = ut
+		}
+	}
+	target := locations[0]
+	worst := threat(v, target) - gamelogic.DefensePower(unitsAt(v.Units, target))
+	for _, loc := range locations {
+		exposure := threat(v, loc) - gamelogic.DefensePower(unitsAt(v.Units, loc))
+		if exposure > worst {
+			target = loc
+			worst = exposure
+		}
+	}
+	return spawnCommand(target, best.Rank)
+}
+
+// threat is the attack power of every known enemy unit in or next to loc.
+func threat(v View, loc gamelogic.Location) int {
+	enemies := enemyUnitsAt(v, loc)
+	for _, neighbor := range gamelogic.GetNeighbors(loc) {
+		enemies = append(enemies, enemyUnitsAt(v, neighbor)...)
+	}
+	return gamelogic.AttackPower(enemies)
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// inTempDir runs the test from an empty directory so that no peril.conf is
+// found unless the test writes one.
+func inTempDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func load(t *testing.T, args ...string) *Config {
+	t.Helper()
+	cfg, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), args)
+	if err != nil {
+		t.Fatalf("Load(%q) error: %v", args, err)
+	}
+	return cfg
+}
+
+func TestLoadWithoutFile(t *testing.T) {
+	inTempDir(t)
+	cfg := load(t)
+	want := Default()
+	if *cfg != *want {
+		t.Errorf("Load() = %+v, want the defaults %+v", cfg, want)
+	}
+}
+
+func TestLoadEnvOnly(t *testing.T) {
+	inTempDir(t)
+	t.Setenv("PERIL_USERNAME", "alice")
+	t.Setenv("PERIL_PREFETCH", "3")
+	t.Setenv("PERIL_LOG_WRITE_DELAY", "250ms")
+
+	cfg := load(t)
+	if cfg.Username != "alice" || cfg.Prefetch != 3 || cfg.LogWriteDelay != 250*time.Millisecond {
+		t.Errorf("Load() = %+v, want the environment applied", cfg)
+	}
+	if cfg.File != "" {
+		t.Errorf("Load() read %s, want no file", cfg.File)
+	}
+
+	t.Setenv("PERIL_PREFETCH", "lots")
+	if _, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), nil); err == nil {
+		t.Error("Load() accepted a bad PERIL_PREFETCH")
+	}
+}
+
+func TestLoadFileAndEnv(t *testing.T) {
+	dir := inTempDir(t)
+	conf := "# shared settings\nusername = alice\ngame = europe\n\nprefetch = 5\n"
+	if err := os.WriteFile(filepath.Join(dir, DefaultFile), []byte(conf), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PERIL_GAME", "asia")
+
+	cfg := load(t)
+	if cfg.File != DefaultFile {
+		t.Errorf("Load() read %q, want %q", cfg.File, DefaultFile)
+	}
+	if cfg.Username != "alice" || cfg.Prefetch != 5 {
+		t.Errorf("Load() = %+v, want the file applied", cfg)
+	}
+	if cfg.GameID != "asia" {
+		t.Errorf("game = %q, want the environment to override the file", cfg.GameID)
+	}
+
+	if _, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), []string{"-config", filepath.Join(dir, "missing.conf")}); err == nil {
+		t.Error("Load() ignored a missing config file that was asked for")
+	}
+}
+
+func TestLoadFlagsOverrideAll(t *testing.T) {
+	dir := inTempDir(t)
+	path := filepath.Join(dir, "other.conf")
+	if err := os.WriteFile(path, []byte("username = alice\nprefetch = 5\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PERIL_USERNAME", "bob")
+	t.Setenv("PERIL_PREFETCH", "7")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	count := fs.Int("count", 1, "")
+	cfg, err := Load(fs, []string{"-config", path, "-username", "carol", "-count", "4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.File != path {
+		t.Errorf("Load() read %q, want %q", cfg.File, path)
+	}
+	if cfg.Username != "carol" {
+		t.Errorf("username = %q, want the flag to win", cfg.Username)
+	}
+	if cfg.Prefetch != 7 {
+		t.Errorf("prefetch = %d, want the environment to win without a flag", cfg.Prefetch)
+	}
+	if *count != 4 {
+		t.Errorf("the caller's own flag is %d, want 4", *count)
+	}
+}
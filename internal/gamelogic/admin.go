@@ -0,0 +1,59 @@
+package gamelogic
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+// HandleAdmin shows a moderation action and reports whether it removes this
+// player from the game, in which case the client must disconnect.
+func (gs *GameState) HandleAdmin(ev routing.AdminEvent) bool {
+	defer fmt.Fprintln(gs.out, "------------------------")
+	fmt.Fprintln(gs.out)
+	fmt.Fprintln(gs.out, "==== Admin ====")
+	if ev.Username != gs.GetUsername() {
+		switch ev.Action {
+		case routing.AdminMute:
+			fmt.Fprintf(gs.out, "%s was muted until %s: %s\n", ev.Username, ev.Until.Format(time.TimeOnly), ev.Reason)
+		case routing.AdminUnmute:
+			fmt.Fprintf(gs.out, "%s was unmuted.\n", ev.Username)
+		default:
+			fmt.Fprintf(gs.out, "%s was %s: %s\n", ev.Username, pastTense(ev.Action), ev.Reason)
+		}
+		return false
+	}
+
+	switch ev.Action {
+	case routing.AdminKick, routing.AdminBan:
+		fmt.Fprintf(gs.out, "You have been %s: %s\n", pastTense(ev.Action), ev.Reason)
+		return true
+	case routing.AdminMute:
+		gs.emit(Muted{Until: ev.Until})
+		fmt.Fprintf(gs.out, "You are muted until %s: %s\n", ev.Until.Format(time.TimeOnly), ev.Reason)
+	case routing.AdminUnmute:
+		gs.emit(Muted{})
+		fmt.Fprintln(gs.out, "You are no longer muted.")
+	}
+	return false
+}
+
+func (gs *GameState) checkMuted() error {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	if time.Now().Before(gs.MutedUntil) {
+		return fmt.Errorf("you are muted for another %v", time.Until(gs.MutedUntil).Round(time.Second))
+	}
+	return nil
+}
+
+func pastTense(action string) string {
+	switch action {
+	case routing.AdminKick:
+		return "kicked"
+	case routing.AdminBan:
+		return "banned"
+	}
+	return action
+}
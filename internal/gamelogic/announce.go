@@ -0,0 +1,25 @@
+package gamelogic
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+// HandleAnnouncement shows a server announcement in a banner that stands
+// out from the rest of the game output.
+func (gs *GameState) HandleAnnouncement(a routing.Announcement) {
+	title := "ANNOUNCEMENT"
+	if a.To != "" {
+		title = "ANNOUNCEMENT FOR " + strings.ToUpper(a.To)
+	}
+	banner := strings.Repeat("*", len(title)+10)
+	fmt.Fprintln(gs.out)
+	fmt.Fprintln(gs.out, banner)
+	fmt.Fprintf(gs.out, "**** %s ****\n", title)
+	fmt.Fprintln(gs.out, banner)
+	fmt.Fprintf(gs.out, "[%s] %s: %s\n", a.SentAt.Format(time.Kitchen), a.From, a.Text)
+	fmt.Fprintln(gs.out, banner)
+}
@@ -0,0 +1,100 @@
+package gamelogic
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+// CommandChat builds the chat message for the say, whisper, teamsay and
+// history commands. Messages go to the server, which moderates them and
+// delivers them to the right channel.
+func (gs *GameState) CommandChat(words []string) (routing.ChatMessage, error) {
+	if err := gs.checkMuted(); err != nil && words[0] != "history" {
+		return routing.ChatMessage{}, err
+	}
+	msg := routing.ChatMessage{
+		From:   gs.GetUsername(),
+		SentAt: time.Now(),
+	}
+	switch words[0] {
+	case "say":
+		if len(words) < 2 {
+			return routing.ChatMessage{}, errors.New("usage: say <message>")
+		}
+		msg.Channel = routing.ChatChannelGlobal
+		msg.Text = strings.Join(words[1:], " ")
+	case "whisper":
+		if len(words) < 3 {
+			return routing.ChatMessage{}, errors.New("usage: whisper <username> <message>")
+		}
+		msg.Channel = routing.ChatChannelPrivate
+		msg.To = words[1]
+		msg.Text = strings.Join(words[2:], " ")
+	case "teamsay":
+		if len(words) < 2 {
+			return routing.ChatMessage{}, errors.New("usage: teamsay <message>")
+		}
+		team := gs.GetTeam()
+		if team == "" {
+			return routing.ChatMessage{}, errors.New("you are not on a team, ask the server admin to put you on one")
+		}
+		msg.Channel = routing.ChatChannelTeam
+		msg.Team = team
+		msg.Text = strings.Join(words[1:], " ")
+	case "history":
+		msg.Channel = routing.ChatChannelHistory
+	default:
+		return routing.ChatMessage{}, fmt.Errorf("unknown chat command %s", words[0])
+	}
+	return msg, nil
+}
+
+// SetTeam records the team the server put the player on.
+func (gs *GameState) SetTeam(team string) {
+	if team != gs.GetTeam() {
+		gs.emit(TeamJoined{Team: team})
+	}
+}
+
+func (gs *GameState) GetTeam() string {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return gs.Team
+}
+
+// HandleChat prints a chat message and reports whether it was shown. Team
+// messages for a team the player has since left are ignored.
+func (gs *GameState) HandleChat(msg routing.ChatMessage) bool {
+	if msg.Channel == routing.ChatChannelJoinTeam {
+		if msg.From != routing.ServerUsername {
+			return false
+		}
+		gs.SetTeam(msg.Team)
+		fmt.Fprintln(gs.out)
+		if msg.Team == "" {
+			fmt.Fprintln(gs.out, "The server took you off your team.")
+		} else {
+			fmt.Fprintf(gs.out, "The server put you on team %s.\n", msg.Team)
+		}
+		return true
+	}
+	if msg.Channel == routing.ChatChannelTeam && msg.Team != gs.GetTeam() {
+		return false
+	}
+	fmt.Fprintln(gs.out)
+	prefix := "[" + msg.Channel + "]"
+	if msg.Channel == routing.ChatChannelTeam {
+		prefix = "[team " + msg.Team + "]"
+	} else if msg.Channel == routing.ChatChannelPrivate && msg.From == gs.GetUsername() {
+		prefix = "[to " + msg.To + "]"
+	}
+	if msg.History {
+		prefix = "[history " + msg.SentAt.Format(time.Kitchen) + "]" + prefix
+	}
+	fmt.Fprintf(gs.out, "%s %s: %s\n", prefix, msg.From, msg.Text)
+	return true
+}
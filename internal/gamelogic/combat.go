@@ -0,0 +1,249 @@
+package gamelogic
+
+import (
+	"math/rand"
+	"sort"
+)
+
+type CombatMode string
+
+const (
+	CombatModeProportional CombatMode = "proportional"
+	CombatModeDice         CombatMode = "dice"
+
+	// combatModeLegacyProportional is what proportional combat was called in
+	// older saves and event logs.
+	combatModeLegacyProportional CombatMode = "deterministic"
+)
+
+func getAllCombatModes() map[CombatMode]struct{} {
+	return map[CombatMode]struct{}{
+		CombatModeProportional: {},
+		CombatModeDice:         {},
+	}
+}
+
+func IsValidCombatMode(mode string) bool {
+	_, ok := getAllCombatModes()[CombatMode(mode)]
+	return ok
+}
+
+type BattleWinner int
+
+const (
+	BattleDraw BattleWinner = iota
+	BattleAttackerWon
+	BattleDefenderWon
+)
+
+type UnitCasualty struct {
+	Owner     string
+	UnitID    int
+	Rank      UnitRank
+	Damage    int
+	Killed    bool
+	RetreatTo Location
+}
+
+type BattleResult struct {
+	Winner             BattleWinner
+	AttackerPower      int
+	DefenderPower      int
+	AttackerCasualties []UnitCasualty
+	DefenderCasualties []UnitCasualty
+}
+
+type CombatResolver interface {
+	Resolve(attackers, defenders []Unit, rng *rand.Rand) BattleResult
+}
+
+func getCombatResolver(mode CombatMode) CombatResolver {
+	if mode == CombatModeDice {
+		return diceResolver{}
+	}
+	return proportionalResolver{}
+}
+
+// ResolveBattle fights a single battle. The same mode, units and seed always
+// produce the same result.
+func ResolveBattle(mode CombatMode, attackers, defenders []Unit, seed int64) BattleResult {
+	rng := rand.New(rand.NewSource(seed))
+	return getCombatResolver(mode).Resolve(attackers, defenders, rng)
+}
+
+// proportionalResolver compares summed power. Each side takes damage in
+// proportion to the other side's share of the total power, spread over its
+// weakest units first, and the side with more power wins.
+type proportionalResolver struct{}
+
+func (proportionalResolver) Resolve(attackers, defenders []Unit, rng *rand.Rand) BattleResult {
+	result := BattleResult{
+		AttackerPower: unitsToAttackPower(attackers),
+		DefenderPower: unitsToDefensePower(defenders),
+	}
+	if result.AttackerPower > result.DefenderPower {
+		result.Winner = BattleAttackerWon
+	} else if result.DefenderPower > result.AttackerPower {
+		result.Winner = BattleDefenderWon
+	} else {
+		result.Winner = BattleDraw
+	}
+
+	totalPower := result.AttackerPower + result.DefenderPower
+	if totalPower == 0 {
+		return result
+	}
+	attackerDamage := ceilDiv(unitsToHitPoints(attackers)*result.DefenderPower, totalPower)
+	defenderDamage := ceilDiv(unitsToHitPoints(defenders)*result.AttackerPower, totalPower)
+	result.AttackerCasualties = dealDamage(sortedByStrength(attackers, attackStat, true), attackerDamage)
+	result.DefenderCasualties = dealDamage(sortedByStrength(defenders, defenseStat, true), defenderDamage)
+	return result
+}
+
+// diceResolver fights Risk-style rounds. The strongest attacking units roll
+// up to three dice and the strongest defenders up to two, each die getting a
+// bonus from the unit's stats. Paired dice are compared highest first, ties
+// go to the defender, and the unit that rolled the lower die loses a hit
+// point. Rounds continue until one side has no units left.
+type diceResolver struct{}
+
+const (
+	maxAttackDice  = 3
+	maxDefenseDice = 2
+	diceBonusStep  = 5
+)
+
+func (diceResolver) Resolve(attackers, defenders []Unit, rng *rand.Rand) BattleResult {
+	result := BattleResult{
+		AttackerPower: unitsToAttackPower(attackers),
+		DefenderPower: unitsToDefensePower(defenders),
+	}
+	attacking := sortedByStrength(attackers, attackStat, false)
+	defending := sortedByStrength(defenders, defenseStat, false)
+	attackerDamage := map[int]int{}
+	defenderDamage := map[int]int{}
+
+	for len(attacking) > 0 && len(defending) > 0 {
+		attackRolls := rollDice(attacking, maxAttackDice, rng, attackStat)
+		defenseRolls := rollDice(defending, maxDefenseDice, rng, defenseStat)
+
+		for i := 0; i < len(attackRolls) && i < len(defenseRolls); i++ {
+			if attackRolls[i].value > defenseRolls[i].value {
+				defenderDamage[defending[defenseRolls[i].index].ID]++
+			} else {
+				attackerDamage[attacking[attackRolls[i].index].ID]++
+			}
+		}
+		attacking = survivors(attacking, attackerDamage)
+		defending = survivors(defending, defenderDamage)
+	}
+
+	if len(attacking) > 0 {
+		result.Winner = BattleAttackerWon
+	} else if len(defending) > 0 {
+		result.Winner = BattleDefenderWon
+	} else {
+		result.Winner = BattleDraw
+	}
+	result.AttackerCasualties = damageToCasualties(sortedByStrength(attackers, attackStat, false), attackerDamage)
+	result.DefenderCasualties = damageToCasualties(sortedByStrength(defenders, defenseStat, false), defenderDamage)
+	return result
+}
+
+type diceRoll struct {
+	index int
+	value int
+}
+
+func rollDice(units []Unit, maxDice int, rng *rand.Rand, stat func(UnitType) int) []diceRoll {
+	rolls := []diceRoll{}
+	for i := 0; i < len(units) && i < maxDice; i++ {
+		bonus := 0
+		if ut, ok := GetUnitType(units[i].Rank); ok {
+			bonus = stat(ut) / diceBonusStep
+		}
+		rolls = append(rolls, diceRoll{
+			index: i,
+			value: rng.Intn(6) + 1 + bonus,
+		})
+	}
+	sort.SliceStable(rolls, func(i, j int) bool { return rolls[i].value > rolls[j].value })
+	return rolls
+}
+
+func survivors(units []Unit, damage map[int]int) []Unit {
+	remaining := []Unit{}
+	for _, unit := range units {
+		if damage[unit.ID] < unitHitPoints(unit) {
+			remaining = append(remaining, unit)
+		}
+	}
+	return remaining
+}
+
+// dealDamage spreads damage over units in order, each unit absorbing up to
+// its remaining hit points.
+func dealDamage(units []Unit, damage int) []UnitCasualty {
+	casualties := []UnitCasualty{}
+	for _, unit := range units {
+		if damage <= 0 {
+			break
+		}
+		hp := unitHitPoints(unit)
+		dealt := min(hp, damage)
+		damage -= dealt
+		casualties = append(casualties, UnitCasualty{
+			UnitID: unit.ID,
+			Rank:   unit.Rank,
+			Damage: dealt,
+			Killed: dealt >= hp,
+		})
+	}
+	return casualties
+}
+
+func damageToCasualties(units []Unit, damage map[int]int) []UnitCasualty {
+	casualties := []UnitCasualty{}
+	for _, unit := range units {
+		if damage[unit.ID] == 0 {
+			continue
+		}
+		casualties = append(casualties, UnitCasualty{
+			UnitID: unit.ID,
+			Rank:   unit.Rank,
+			Damage: damage[unit.ID],
+			Killed: damage[unit.ID] >= unitHitPoints(unit),
+		})
+	}
+	return casualties
+}
+
+func attackStat(ut UnitType) int {
+	return ut.Attack
+}
+
+func defenseStat(ut UnitType) int {
+	return ut.Defense
+}
+
+// sortedByStrength orders units strongest first, or weakest first when
+// weakestFirst is set, breaking ties by ID so the order never depends on map
+// iteration.
+func sortedByStrength(units []Unit, stat func(UnitType) int, weakestFirst bool) []Unit {
+	sorted := append([]Unit{}, units...)
+	strength := func(u Unit) int {
+		ut, _ := GetUnitType(u.Rank)
+		return stat(ut)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if strength(sorted[i]) != strength(sorted[j]) {
+			return (strength(sorted[i]) > strength(sorted[j])) != weakestFirst
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	return sorted
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
@@ -0,0 +1,194 @@
+package gamelogic
+
+import (
+	"reflect"
+	"testing"
+)
+
+func infantry(id int) Unit {
+	return Unit{ID: id, Rank: RankInfantry, Location: "asia"}
+}
+
+func cavalry(id int) Unit {
+	return Unit{ID: id, Rank: RankCavalry, Location: "asia"}
+}
+
+func TestProportionalResolver(t *testing.T) {
+	tests := []struct {
+		name      string
+		attackers []Unit
+		defenders []Unit
+		want      BattleResult
+	}{
+		{
+			name:      "even fight is a draw and kills both",
+			attackers: []Unit{infantry(1)},
+			defenders: []Unit{infantry(1)},
+			want: BattleResult{
+				Winner:             BattleDraw,
+				AttackerPower:      1,
+				DefenderPower:      1,
+				AttackerCasualties: []UnitCasualty{{UnitID: 1, Rank: RankInfantry, Damage: 1, Killed: true}},
+				DefenderCasualties: []UnitCasualty{{UnitID: 1, Rank: RankInfantry, Damage: 1, Killed: true}},
+			},
+		},
+		{
+			name:      "weakest attacker with the lowest ID falls first",
+			attackers: []Unit{infantry(2), infantry(1)},
+			defenders: []Unit{infantry(1)},
+			want: BattleResult{
+				Winner:             BattleAttackerWon,
+				AttackerPower:      2,
+				DefenderPower:      1,
+				AttackerCasualties: []UnitCasualty{{UnitID: 1, Rank: RankInfantry, Damage: 1, Killed: true}},
+				DefenderCasualties: []UnitCasualty{{UnitID: 1, Rank: RankInfantry, Damage: 1, Killed: true}},
+			},
+		},
+		{
+			name:      "cavalry survives with damage",
+			attackers: []Unit{cavalry(1)},
+			defenders: []Unit{infantry(1)},
+			want: BattleResult{
+				Winner:             BattleAttackerWon,
+				AttackerPower:      5,
+				DefenderPower:      1,
+				AttackerCasualties: []UnitCasualty{{UnitID: 1, Rank: RankCavalry, Damage: 1, Killed: false}},
+				DefenderCasualties: []UnitCasualty{{UnitID: 1, Rank: RankInfantry, Damage: 1, Killed: true}},
+			},
+		},
+		{
+			name:      "wounded cavalry fights at half strength",
+			attackers: []Unit{{ID: 1, Rank: RankCavalry, HP: 1}},
+			defenders: []Unit{cavalry(1)},
+			want: BattleResult{
+				Winner:             BattleDefenderWon,
+				AttackerPower:      2,
+				DefenderPower:      5,
+				AttackerCasualties: []UnitCasualty{{UnitID: 1, Rank: RankCavalry, Damage: 1, Killed: true}},
+				DefenderCasualties: []UnitCasualty{{UnitID: 1, Rank: RankCavalry, Damage: 1, Killed: false}},
+			},
+		},
+		{
+			name:      "no defenders",
+			attackers: []Unit{infantry(1)},
+			want: BattleResult{
+				Winner:             BattleAttackerWon,
+				AttackerPower:      1,
+				AttackerCasualties: []UnitCasualty{},
+				DefenderCasualties: []UnitCasualty{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ResolveBattle(CombatModeProportional, tt.attackers, tt.defenders, 1)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveBattle() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiceResolver(t *testing.T) {
+	killed := func(u Unit) UnitCasualty {
+		return UnitCasualty{UnitID: u.ID, Rank: u.Rank, Damage: 1, Killed: true}
+	}
+	tests := []struct {
+		name      string
+		attackers []Unit
+		defenders []Unit
+		seed      int64
+		want      BattleResult
+	}{
+		{
+			name:      "attacker wins a duel",
+			attackers: []Unit{infantry(1)},
+			defenders: []Unit{infantry(1)},
+			seed:      1,
+			want: BattleResult{
+				Winner:             BattleAttackerWon,
+				AttackerPower:      1,
+				DefenderPower:      1,
+				AttackerCasualties: []UnitCasualty{},
+				DefenderCasualties: []UnitCasualty{killed(infantry(1))},
+			},
+		},
+		{
+			name:      "defender wins a duel",
+			attackers: []Unit{infantry(1)},
+			defenders: []Unit{infantry(1)},
+			seed:      3,
+			want: BattleResult{
+				Winner:             BattleDefenderWon,
+				AttackerPower:      1,
+				DefenderPower:      1,
+				AttackerCasualties: []UnitCasualty{killed(infantry(1))},
+				DefenderCasualties: []UnitCasualty{},
+			},
+		},
+		{
+			name:      "attacker loses an infantry on the way",
+			attackers: []Unit{infantry(1), infantry(2), cavalry(3)},
+			defenders: []Unit{infantry(1), infantry(2)},
+			seed:      2,
+			want: BattleResult{
+				Winner:             BattleAttackerWon,
+				AttackerPower:      7,
+				DefenderPower:      2,
+				AttackerCasualties: []UnitCasualty{killed(infantry(1))},
+				DefenderCasualties: []UnitCasualty{killed(infantry(1)), killed(infantry(2))},
+			},
+		},
+		{
+			name:      "cavalry is wounded but survives",
+			attackers: []Unit{infantry(1), infantry(2), cavalry(3)},
+			defenders: []Unit{infantry(1), infantry(2)},
+			seed:      3,
+			want: BattleResult{
+				Winner:             BattleAttackerWon,
+				AttackerPower:      7,
+				DefenderPower:      2,
+				AttackerCasualties: []UnitCasualty{{UnitID: 3, Rank: RankCavalry, Damage: 1, Killed: false}},
+				DefenderCasualties: []UnitCasualty{killed(infantry(1)), killed(infantry(2))},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ResolveBattle(CombatModeDice, tt.attackers, tt.defenders, tt.seed)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveBattle() = %+v, want %+v", got, tt.want)
+			}
+			again := ResolveBattle(CombatModeDice, tt.attackers, tt.defenders, tt.seed)
+			if !reflect.DeepEqual(got, again) {
+				t.Errorf("the same seed gave %+v and then %+v", got, again)
+			}
+		})
+	}
+}
+
+func TestDiceResolverEndsWithOneSide(t *testing.T) {
+	attackers := []Unit{infantry(1), cavalry(2), cavalry(3)}
+	defenders := []Unit{infantry(1), infantry(2), cavalry(3)}
+	for seed := int64(1); seed <= 50; seed++ {
+		got := ResolveBattle(CombatModeDice, attackers, defenders, seed)
+		attackersLeft := len(attackers) - countKilled(got.AttackerCasualties)
+		defendersLeft := len(defenders) - countKilled(got.DefenderCasualties)
+		if attackersLeft > 0 && defendersLeft > 0 {
+			t.Fatalf("seed %d: both sides have units left: %+v", seed, got)
+		}
+		if got.Winner == BattleAttackerWon && attackersLeft == 0 || got.Winner == BattleDefenderWon && defendersLeft == 0 {
+			t.Fatalf("seed %d: the winner has no units left: %+v", seed, got)
+		}
+	}
+}
+
+func countKilled(casualties []UnitCasualty) int {
+	killed := 0
+	for _, c := range casualties {
+		if c.Killed {
+			killed++
+		}
+	}
+	return killed
+}
@@ -0,0 +1,141 @@
+package gamelogic
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+type PactType string
+
+const (
+	PactAlliance      PactType = "alliance"
+	PactNonAggression PactType = "non-aggression pact"
+)
+
+type DiplomacyAction string
+
+const (
+	DiplomacyPropose DiplomacyAction = "propose"
+	DiplomacyAccept  DiplomacyAction = "accept"
+	DiplomacyBreak   DiplomacyAction = "break"
+)
+
+type Diplomacy struct {
+	From   string
+	To     string
+	Action DiplomacyAction
+	Pact   PactType
+}
+
+// CommandDiplomacy handles the ally, pact, accept and break commands and
+// returns the message to send to the other player.
+func (gs *GameState) CommandDiplomacy(words []string) (Diplomacy, error) {
+	if len(words) != 2 {
+		return Diplomacy{}, fmt.Errorf("usage: %s <username>", words[0])
+	}
+	username := gs.GetUsername()
+	other := words[1]
+	if other == username {
+		return Diplomacy{}, errors.New("you can not make a treaty with yourself")
+	}
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	d := Diplomacy{
+		From: username,
+		To:   other,
+	}
+	switch words[0] {
+	case "ally", "pact":
+		d.Action = DiplomacyPropose
+		d.Pact = PactAlliance
+		if words[0] == "pact" {
+			d.Pact = PactNonAggression
+		}
+		if current, ok := gs.Pacts[other]; ok && current == d.Pact {
+			return Diplomacy{}, fmt.Errorf("you already have a(n) %s with %s", d.Pact, other)
+		}
+		gs.proposed[other] = d.Pact
+		fmt.Fprintf(gs.out, "You proposed a(n) %s to %s.\n", d.Pact, other)
+	case "accept":
+		pact, ok := gs.proposals[other]
+		if !ok {
+			return Diplomacy{}, fmt.Errorf("%s has not proposed a treaty to you", other)
+		}
+		gs.record(PactSigned{With: other, Pact: pact})
+		d.Action = DiplomacyAccept
+		d.Pact = pact
+		fmt.Fprintf(gs.out, "You accepted a(n) %s with %s.\n", pact, other)
+	case "break":
+		pact, ok := gs.Pacts[other]
+		if !ok {
+			return Diplomacy{}, fmt.Errorf("you have no treaty with %s", other)
+		}
+		gs.record(PactBroken{With: other, Pact: pact})
+		d.Action = DiplomacyBreak
+		d.Pact = pact
+		fmt.Fprintf(gs.out, "You broke your %s with %s.\n", pact, other)
+	default:
+		return Diplomacy{}, fmt.Errorf("unknown diplomacy command %s", words[0])
+	}
+	return d, nil
+}
+
+func (gs *GameState) HandleDiplomacy(d Diplomacy) {
+	defer fmt.Fprintln(gs.out, "------------------------")
+	fmt.Fprintln(gs.out)
+	fmt.Fprintln(gs.out, "==== Diplomacy ====")
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	switch d.Action {
+	case DiplomacyPropose:
+		gs.proposals[d.From] = d.Pact
+		fmt.Fprintf(gs.out, "%s proposes a(n) %s. Type 'accept %s' to agree.\n", d.From, d.Pact, d.From)
+	case DiplomacyAccept:
+		pact, ok := gs.proposed[d.From]
+		if !ok || pact != d.Pact {
+			fmt.Fprintf(gs.out, "%s accepted a(n) %s you never proposed.\n", d.From, d.Pact)
+			return
+		}
+		gs.record(PactSigned{With: d.From, Pact: pact})
+		fmt.Fprintf(gs.out, "%s accepted your %s!\n", d.From, pact)
+	case DiplomacyBreak:
+		gs.record(PactBroken{With: d.From, Pact: d.Pact})
+		fmt.Fprintf(gs.out, "%s has broken your %s! Your units are no longer safe from them.\n", d.From, d.Pact)
+	}
+}
+
+func (gs *GameState) CommandTreaties() {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	if len(gs.Pacts) == 0 && len(gs.proposals) == 0 {
+		fmt.Fprintln(gs.out, "You have no treaties.")
+		return
+	}
+	for _, other := range sortedKeys(gs.Pacts) {
+		fmt.Fprintf(gs.out, "* %s with %s\n", gs.Pacts[other], other)
+	}
+	for _, other := range sortedKeys(gs.proposals) {
+		fmt.Fprintf(gs.out, "* %s proposed a(n) %s\n", other, gs.proposals[other])
+	}
+}
+
+// hasPact reports whether the player has any treaty with the other player.
+// Both alliances and non-aggression pacts prevent war.
+func (gs *GameState) hasPact(other string) bool {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	_, ok := gs.Pacts[other]
+	return ok
+}
+
+func sortedKeys(m map[string]PactType) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,68 @@
+package gamelogic
+
+import (
+	"fmt"
+	"time"
+)
+
+const startingTreasury = 20
+
+// IncomeInterval is how often territories pay out when turn mode is disabled.
+const IncomeInterval = 30 * time.Second
+
+func getLocationIncome() map[Location]int {
+	return map[Location]int{
+		"americas":   5,
+		"europe":     5,
+		"africa":     3,
+		"asia":       7,
+		"australia":  2,
+		"antarctica": 1,
+	}
+}
+
+func (gs *GameState) GetIncome() int {
+	incomes := getLocationIncome()
+	income := 0
+	for _, loc := range gs.ownedTerritories() {
+		income += incomes[loc]
+	}
+	return income
+}
+
+func (gs *GameState) GetTreasury() int {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return gs.Treasury
+}
+
+// CollectIncome pays the player for every territory they own and returns
+// the amount collected.
+func (gs *GameState) CollectIncome() int {
+	income := gs.GetIncome()
+	gs.emit(IncomeCollected{Amount: income})
+	return income
+}
+
+// CollectIncomeOnInterval pays out income when turn mode is off and the game
+// is not paused. Turn mode pays out at the start of every round instead.
+func (gs *GameState) CollectIncomeOnInterval() int {
+	if gs.IsPaused() || gs.GetTurn().Enabled {
+		return 0
+	}
+	return gs.CollectIncome()
+}
+
+// spawnUnit pays for u and adds it to the army under the next unit ID.
+// IDs are never reused, not even across games, so a unit's ID stays stable
+// for as long as the player has it.
+func (gs *GameState) spawnUnit(u Unit, cost int) (Unit, error) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	if gs.Treasury < cost {
+		return Unit{}, fmt.Errorf("you need %d gold but only have %d", cost, gs.Treasury)
+	}
+	u.ID = max(gs.NextUnitID, 1)
+	gs.record(UnitSpawned{Unit: u, Cost: cost})
+	return u, nil
+}
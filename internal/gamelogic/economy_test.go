@@ -0,0 +1,73 @@
+package gamelogic
+
+import "testing"
+
+func TestCollectIncome(t *testing.T) {
+	tests := []struct {
+		name        string
+		territories map[Location]string
+		want        int
+	}{
+		{"nothing owned", map[Location]string{}, 0},
+		{"one territory", map[Location]string{"asia": "alice"}, 7},
+		{"several territories", map[Location]string{"asia": "alice", "europe": "alice", "antarctica": "alice"}, 13},
+		{"others' territory pays nothing", map[Location]string{"asia": "bob", "africa": "alice"}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := NewGameState("alice")
+			gs.Territories = tt.territories
+			if got := gs.CollectIncome(); got != tt.want {
+				t.Errorf("CollectIncome() = %d, want %d", got, tt.want)
+			}
+			if gs.Treasury != startingTreasury+tt.want {
+				t.Errorf("treasury = %d, want %d", gs.Treasury, startingTreasury+tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectIncomeOnInterval(t *testing.T) {
+	gs := NewGameState("alice")
+	gs.Territories = map[Location]string{"asia": "alice"}
+
+	gs.GamePause.Paused = true
+	if got := gs.CollectIncomeOnInterval(); got != 0 {
+		t.Errorf("paused game paid %d", got)
+	}
+	gs.GamePause.Paused = false
+	gs.PlayerPause.Paused = true
+	if got := gs.CollectIncomeOnInterval(); got != 0 {
+		t.Errorf("paused player was paid %d", got)
+	}
+	gs.PlayerPause.Paused = false
+	gs.Turn.Enabled = true
+	if got := gs.CollectIncomeOnInterval(); got != 0 {
+		t.Errorf("turn mode paid %d on the interval", got)
+	}
+	gs.Turn.Enabled = false
+	if got := gs.CollectIncomeOnInterval(); got != 7 {
+		t.Errorf("free play paid %d, want 7", got)
+	}
+}
+
+func TestSpawnUnitIDs(t *testing.T) {
+	gs := NewGameState("alice")
+	for want := 1; want <= 3; want++ {
+		u, err := gs.spawnUnit(Unit{Rank: RankInfantry, Location: "asia"}, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.ID != want {
+			t.Errorf("unit ID = %d, want %d", u.ID, want)
+		}
+	}
+	delete(gs.Player.Units, 3)
+	u, err := gs.spawnUnit(Unit{Rank: RankInfantry, Location: "asia"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.ID != 4 {
+		t.Errorf("unit ID after a loss = %d, want 4, IDs must not be reused", u.ID)
+	}
+}
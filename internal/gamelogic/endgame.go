@@ -0,0 +1,71 @@
+package gamelogic
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+func (gs *GameState) HandleElimination(pe routing.PlayerEliminated) {
+	defer fmt.Fprintln(gs.out, "------------------------")
+	fmt.Fprintln(gs.out)
+	fmt.Fprintln(gs.out, "==== Player Eliminated ====")
+	if pe.Username != gs.GetUsername() {
+		fmt.Fprintf(gs.out, "%s has been eliminated: %s\n", pe.Username, pe.Reason)
+		return
+	}
+	gs.emit(Eliminated{Reason: pe.Reason})
+	fmt.Fprintf(gs.out, "You have been eliminated: %s\n", pe.Reason)
+	fmt.Fprintln(gs.out, "You can keep watching, but you can no longer give orders.")
+}
+
+func (gs *GameState) HandleGameOver(over routing.GameOver) {
+	defer fmt.Fprintln(gs.out, "------------------------")
+	fmt.Fprintln(gs.out)
+	fmt.Fprintln(gs.out, "==== Game Over ====")
+	gs.emit(GameEnded{Winner: over.Winner, Reason: over.Reason})
+	if over.Winner == "" {
+		fmt.Fprintf(gs.out, "The game ended without a winner: %s\n", over.Reason)
+	} else if over.Winner == gs.GetUsername() {
+		fmt.Fprintf(gs.out, "You won the game: %s\n", over.Reason)
+	} else {
+		fmt.Fprintf(gs.out, "%s won the game: %s\n", over.Winner, over.Reason)
+	}
+	PrintStandings(gs.out, over.Standings)
+}
+
+func (gs *GameState) HandleNewGame(ng routing.NewGame) {
+	defer fmt.Fprintln(gs.out, "------------------------")
+	fmt.Fprintln(gs.out)
+	fmt.Fprintf(gs.out, "==== New Game #%d ====\n", ng.GameNumber)
+	mode := combatModeOrDefault(CombatMode(ng.CombatMode))
+	gs.emit(GameReset{GameNumber: ng.GameNumber, Mode: mode})
+	fmt.Fprintln(gs.out, "Your army and treasury have been reset.")
+	fmt.Fprintf(gs.out, "Wars are fought in %s combat mode.\n", mode)
+}
+
+func PrintStandings(w io.Writer, standings []routing.Standing) {
+	fmt.Fprintln(w, "Final standings:")
+	for i, standing := range standings {
+		fmt.Fprintf(w, "%d. %s: %d territories", i+1, standing.Username, standing.Territories)
+		if standing.Eliminated {
+			fmt.Fprint(w, " (eliminated)")
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// checkPlaying returns an error when the player may no longer give orders.
+func (gs *GameState) checkPlaying() error {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	if gs.GameOver {
+		return errors.New("the game is over, wait for the server to start a new one")
+	}
+	if gs.Eliminated {
+		return errors.New("you have been eliminated and can no longer give orders")
+	}
+	return nil
+}
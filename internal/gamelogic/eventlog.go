@@ -0,0 +1,151 @@
+package gamelogic
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const EventLogDir = "events"
+
+// maxEventSize bounds a single JSONL line. Snapshot events carry the whole
+// army, so they can be much longer than bufio's default of 64KB.
+const maxEventSize = 4 * 1024 * 1024
+
+// EventRecord is one line of an event log. Round is the turn round the event
+// happened in, which lets a replay step through the game turn by turn.
+type EventRecord struct {
+	Seq   int
+	At    time.Time
+	Round int
+	Kind  EventKind
+	Data  json.RawMessage
+}
+
+// EventLog appends a player's events to a JSONL file.
+type EventLog struct {
+	file *os.File
+	seq  int
+	mu   *sync.Mutex
+}
+
+// EventLogPath is where a player's events in a game are logged, one log
+// per game.
+func EventLogPath(gameID, username string) string {
+	return filepath.Join(EventLogDir, gameID, username+".jsonl")
+}
+
+// OpenEventLog opens path for appending and continues its sequence numbers.
+func OpenEventLog(path string) (*EventLog, error) {
+	records, err := ReadEventLog(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("could not create event log directory: %v", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open event log: %v", err)
+	}
+	seq := 0
+	if len(records) > 0 {
+		seq = records[len(records)-1].Seq
+	}
+	return &EventLog{
+		file: f,
+		seq:  seq,
+		mu:   &sync.Mutex{},
+	}, nil
+}
+
+func (l *EventLog) Append(round int, e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("could not encode %s event: %v", e.Kind(), err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.seq++
+	line, err := json.Marshal(EventRecord{
+		Seq:   l.seq,
+		At:    time.Now(),
+		Round: round,
+		Kind:  e.Kind(),
+		Data:  data,
+	})
+	if err != nil {
+		return fmt.Errorf("could not encode event record: %v", err)
+	}
+	_, err = l.file.Write(append(line, '\n'))
+	if err != nil {
+		return fmt.Errorf("could not write to event log: %v", err)
+	}
+	return nil
+}
+
+func (l *EventLog) Close() error {
+	return l.file.Close()
+}
+
+func ReadEventLog(path string) ([]EventRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records := []EventRecord{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEventSize)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec EventRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("could not decode event log line %d: %v", len(records)+1, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read event log: %v", err)
+	}
+	return records, nil
+}
+
+// SetEventLog starts recording every state change to l. It first records a
+// snapshot of the current state, so folding the log never depends on
+// anything that happened before this session.
+func (gs *GameState) SetEventLog(l *EventLog) {
+	s := gs.Snapshot()
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	gs.events = l
+	gs.record(SnapshotRestored{Snapshot: s})
+}
+
+// record applies e and appends it to the event log. The caller must hold
+// the lock.
+func (gs *GameState) record(e Event) {
+	e.apply(gs)
+	if gs.events == nil {
+		return
+	}
+	err := gs.events.Append(gs.Turn.Round, e)
+	if err != nil {
+		log.Println("Trouble recording event: ", err)
+	}
+}
+
+func (gs *GameState) emit(e Event) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	gs.record(e)
+}
@@ -0,0 +1,464 @@
+package gamelogic
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+type EventKind string
+
+const (
+	EventUnitSpawned      EventKind = "unit_spawned"
+	EventUnitsMoved       EventKind = "units_moved"
+	EventUnitCasualty     EventKind = "unit_casualty"
+	EventOpponentSpotted  EventKind = "opponent_spotted"
+	EventPauseChanged     EventKind = "pause_changed"
+	EventTurnChanged      EventKind = "turn_changed"
+	EventIncomeCollected  EventKind = "income_collected"
+	EventTerritoryChanged EventKind = "territory_changed"
+	EventPactSigned       EventKind = "pact_signed"
+	EventPactBroken       EventKind = "pact_broken"
+	EventTeamJoined       EventKind = "team_joined"
+	EventEliminated       EventKind = "eliminated"
+	EventGameEnded        EventKind = "game_ended"
+	EventGameReset        EventKind = "game_reset"
+	EventSnapshotRestored EventKind = "snapshot_restored"
+	EventMuted            EventKind = "muted"
+)
+
+// Event is a single change to a GameState. Commands and handlers validate
+// first and then record an event, so apply never fails. apply is always
+// called with the state's lock held.
+type Event interface {
+	Kind() EventKind
+	String() string
+	apply(gs *GameState)
+}
+
+type UnitSpawned struct {
+	Unit Unit
+	Cost int
+}
+
+func (e UnitSpawned) Kind() EventKind { return EventUnitSpawned }
+
+func (e UnitSpawned) String() string {
+	return fmt.Sprintf("%s spawned %s %v in %s for %d gold", e.Unit.Owner, e.Unit.Rank, e.Unit.ID, e.Unit.Location, e.Cost)
+}
+
+func (e UnitSpawned) apply(gs *GameState) {
+	gs.Player.Units[e.Unit.ID] = e.Unit
+	gs.Treasury -= e.Cost
+	if gs.NextUnitID <= e.Unit.ID {
+		gs.NextUnitID = e.Unit.ID + 1
+	}
+}
+
+type UnitsMoved struct {
+	To      Location
+	UnitIDs []int
+}
+
+func (e UnitsMoved) Kind() EventKind { return EventUnitsMoved }
+
+func (e UnitsMoved) String() string {
+	return fmt.Sprintf("moved units %v to %s", e.UnitIDs, e.To)
+}
+
+func (e UnitsMoved) apply(gs *GameState) {
+	for _, id := range e.UnitIDs {
+		unit, ok := gs.Player.Units[id]
+		if !ok {
+			continue
+		}
+		unit.Location = e.To
+		gs.Player.Units[id] = unit
+	}
+}
+
+type UnitCasualtyApplied struct {
+	Casualty UnitCasualty
+}
+
+func (e UnitCasualtyApplied) Kind() EventKind { return EventUnitCasualty }
+
+func (e UnitCasualtyApplied) String() string {
+	c := e.Casualty
+	switch {
+	case c.Killed:
+		return fmt.Sprintf("%s's %s %v was killed", c.Owner, c.Rank, c.UnitID)
+	case c.RetreatTo != "":
+		return fmt.Sprintf("%s's %s %v took %d damage and retreated to %s", c.Owner, c.Rank, c.UnitID, c.Damage, c.RetreatTo)
+	default:
+		return fmt.Sprintf("%s's %s %v took %d damage", c.Owner, c.Rank, c.UnitID, c.Damage)
+	}
+}
+
+func (e UnitCasualtyApplied) apply(gs *GameState) {
+	c := e.Casualty
+	if c.Owner == gs.Player.Username {
+		gs.Player.Units = applyCasualtyToUnits(gs.Player.Units, c)
+		return
+	}
+	opponent, ok := gs.opponents[c.Owner]
+	if !ok {
+		return
+	}
+	units := map[int]Unit{}
+	for k, v := range opponent.Units {
+		units[k] = v
+	}
+	opponent.Units = applyCasualtyToUnits(units, c)
+	gs.opponents[c.Owner] = opponent
+}
+
+type OpponentSpotted struct {
+	Player Player
+}
+
+func (e OpponentSpotted) Kind() EventKind { return EventOpponentSpotted }
+
+func (e OpponentSpotted) String() string {
+	return fmt.Sprintf("spotted %d of %s's units", len(e.Player.Units), e.Player.Username)
+}
+
+func (e OpponentSpotted) apply(gs *GameState) {
+	gs.opponents[e.Player.Username] = e.Player
+}
+
+// PauseChanged is a pause or resume of the player's game, or of the player
+// alone when Player is set.
+type PauseChanged struct {
+	Paused   bool
+	Player   bool
+	Reason   string
+	ResumeAt time.Time
+}
+
+func (e PauseChanged) Kind() EventKind { return EventPauseChanged }
+
+func (e PauseChanged) String() string {
+	subject := "the game was"
+	if e.Player {
+		subject = "you were"
+	}
+	if !e.Paused {
+		return subject + " resumed"
+	}
+	str := subject + " paused"
+	if e.Reason != "" {
+		str += ": " + e.Reason
+	}
+	if !e.ResumeAt.IsZero() {
+		str += fmt.Sprintf(" until %s", e.ResumeAt.Format(time.TimeOnly))
+	}
+	return str
+}
+
+func (e PauseChanged) apply(gs *GameState) {
+	pause := Pause{}
+	if e.Paused {
+		pause = Pause{Paused: true, Reason: e.Reason, ResumeAt: e.ResumeAt}
+	}
+	if e.Player {
+		gs.PlayerPause = pause
+	} else {
+		gs.GamePause = pause
+	}
+}
+
+type TurnChanged struct {
+	Turn routing.TurnState
+}
+
+func (e TurnChanged) Kind() EventKind { return EventTurnChanged }
+
+func (e TurnChanged) String() string {
+	if !e.Turn.Enabled {
+		return "turn mode was disabled"
+	}
+	return fmt.Sprintf("round %d %s phase began", e.Turn.Round, e.Turn.Phase)
+}
+
+func (e TurnChanged) apply(gs *GameState) {
+	gs.Turn = e.Turn
+}
+
+type IncomeCollected struct {
+	Amount int
+}
+
+func (e IncomeCollected) Kind() EventKind { return EventIncomeCollected }
+
+func (e IncomeCollected) String() string {
+	return fmt.Sprintf("collected %d gold", e.Amount)
+}
+
+func (e IncomeCollected) apply(gs *GameState) {
+	gs.Treasury += e.Amount
+}
+
+type TerritoryChanged struct {
+	Change TerritoryChange
+}
+
+func (e TerritoryChanged) Kind() EventKind { return EventTerritoryChanged }
+
+func (e TerritoryChanged) String() string {
+	if e.Change.Owner == "" {
+		return fmt.Sprintf("%s lost control of %s", e.Change.PreviousOwner, e.Change.Location)
+	}
+	return fmt.Sprintf("%s took control of %s", e.Change.Owner, e.Change.Location)
+}
+
+func (e TerritoryChanged) apply(gs *GameState) {
+	if e.Change.Owner == "" {
+		delete(gs.Territories, e.Change.Location)
+		return
+	}
+	gs.Territories[e.Change.Location] = e.Change.Owner
+}
+
+type PactSigned struct {
+	With string
+	Pact PactType
+}
+
+func (e PactSigned) Kind() EventKind { return EventPactSigned }
+
+func (e PactSigned) String() string {
+	return fmt.Sprintf("signed a(n) %s with %s", e.Pact, e.With)
+}
+
+func (e PactSigned) apply(gs *GameState) {
+	delete(gs.proposals, e.With)
+	delete(gs.proposed, e.With)
+	gs.Pacts[e.With] = e.Pact
+}
+
+type PactBroken struct {
+	With string
+	Pact PactType
+}
+
+func (e PactBroken) Kind() EventKind { return EventPactBroken }
+
+func (e PactBroken) String() string {
+	return fmt.Sprintf("the %s with %s was broken", e.Pact, e.With)
+}
+
+func (e PactBroken) apply(gs *GameState) {
+	delete(gs.Pacts, e.With)
+	delete(gs.proposed, e.With)
+}
+
+type TeamJoined struct {
+	Team string
+}
+
+func (e TeamJoined) Kind() EventKind { return EventTeamJoined }
+
+func (e TeamJoined) String() string {
+	if e.Team == "" {
+		return "left their team"
+	}
+	return fmt.Sprintf("joined team %s", e.Team)
+}
+
+func (e TeamJoined) apply(gs *GameState) {
+	gs.Team = e.Team
+}
+
+type Eliminated struct {
+	Reason string
+}
+
+func (e Eliminated) Kind() EventKind { return EventEliminated }
+
+func (e Eliminated) String() string {
+	return fmt.Sprintf("eliminated: %s", e.Reason)
+}
+
+func (e Eliminated) apply(gs *GameState) {
+	gs.Eliminated = true
+}
+
+type GameEnded struct {
+	Winner string
+	Reason string
+}
+
+func (e GameEnded) Kind() EventKind { return EventGameEnded }
+
+func (e GameEnded) String() string {
+	if e.Winner == "" {
+		return fmt.Sprintf("the game ended without a winner: %s", e.Reason)
+	}
+	return fmt.Sprintf("%s won the game: %s", e.Winner, e.Reason)
+}
+
+func (e GameEnded) apply(gs *GameState) {
+	gs.GameOver = true
+}
+
+type GameReset struct {
+	GameNumber int
+	Mode       CombatMode
+}
+
+func (e GameReset) Kind() EventKind { return EventGameReset }
+
+func (e GameReset) String() string {
+	return fmt.Sprintf("game #%d started in %s combat mode", e.GameNumber, e.Mode)
+}
+
+// apply clears everything except NextUnitID, so unit IDs stay unique across
+// games, and Team, which the server assigns for the whole session.
+func (e GameReset) apply(gs *GameState) {
+	gs.GameNumber = e.GameNumber
+	gs.CombatMode = combatModeOrDefault(e.Mode)
+	gs.Player.Units = map[int]Unit{}
+	gs.Turn = routing.TurnState{}
+	gs.Treasury = startingTreasury
+	gs.Territories = map[Location]string{}
+	gs.Eliminated = false
+	gs.GameOver = false
+	gs.Pacts = map[string]PactType{}
+	gs.proposals = map[string]PactType{}
+	gs.proposed = map[string]PactType{}
+	gs.opponents = map[string]Player{}
+}
+
+type Muted struct {
+	Until time.Time
+}
+
+func (e Muted) Kind() EventKind { return EventMuted }
+
+func (e Muted) String() string {
+	if e.Until.IsZero() {
+		return "unmuted"
+	}
+	return fmt.Sprintf("muted until %s", e.Until.Format(time.TimeOnly))
+}
+
+func (e Muted) apply(gs *GameState) {
+	gs.MutedUntil = e.Until
+}
+
+type SnapshotRestored struct {
+	Snapshot Snapshot
+}
+
+func (e SnapshotRestored) Kind() EventKind { return EventSnapshotRestored }
+
+func (e SnapshotRestored) String() string {
+	return fmt.Sprintf("restored a snapshot with %d units and %d gold", len(e.Snapshot.Player.Units), e.Snapshot.Treasury)
+}
+
+func (e SnapshotRestored) apply(gs *GameState) {
+	s := e.Snapshot
+	gs.Player.Units = map[int]Unit{}
+	nextID := s.NextUnitID
+	for k, v := range s.Player.Units {
+		gs.Player.Units[k] = v
+		if k >= nextID {
+			nextID = k + 1
+		}
+	}
+	gs.Treasury = s.Treasury
+	gs.Territories = copyMap(s.Territories)
+	gs.Eliminated = s.Eliminated
+	gs.GameOver = s.GameOver
+	gs.CombatMode = combatModeOrDefault(s.CombatMode)
+	gs.NextUnitID = nextID
+	gs.Pacts = copyMap(s.Pacts)
+	gs.Team = s.Team
+	gs.opponents = copyMap(s.Opponents)
+	gs.proposals = map[string]PactType{}
+	gs.proposed = map[string]PactType{}
+}
+
+// DecodeEvent turns a logged record back into its typed event.
+func DecodeEvent(rec EventRecord) (Event, error) {
+	var e Event
+	var err error
+	switch rec.Kind {
+	case EventUnitSpawned:
+		e, err = decodeEventData[UnitSpawned](rec.Data)
+	case EventUnitsMoved:
+		e, err = decodeEventData[UnitsMoved](rec.Data)
+	case EventUnitCasualty:
+		e, err = decodeEventData[UnitCasualtyApplied](rec.Data)
+	case EventOpponentSpotted:
+		e, err = decodeEventData[OpponentSpotted](rec.Data)
+	case EventPauseChanged:
+		e, err = decodeEventData[PauseChanged](rec.Data)
+	case EventTurnChanged:
+		e, err = decodeEventData[TurnChanged](rec.Data)
+	case EventIncomeCollected:
+		e, err = decodeEventData[IncomeCollected](rec.Data)
+	case EventTerritoryChanged:
+		e, err = decodeEventData[TerritoryChanged](rec.Data)
+	case EventPactSigned:
+		e, err = decodeEventData[PactSigned](rec.Data)
+	case EventPactBroken:
+		e, err = decodeEventData[PactBroken](rec.Data)
+	case EventTeamJoined:
+		e, err = decodeEventData[TeamJoined](rec.Data)
+	case EventEliminated:
+		e, err = decodeEventData[Eliminated](rec.Data)
+	case EventGameEnded:
+		e, err = decodeEventData[GameEnded](rec.Data)
+	case EventGameReset:
+		e, err = decodeEventData[GameReset](rec.Data)
+	case EventSnapshotRestored:
+		e, err = decodeEventData[SnapshotRestored](rec.Data)
+	case EventMuted:
+		e, err = decodeEventData[Muted](rec.Data)
+	default:
+		return nil, fmt.Errorf("unknown event kind %q", rec.Kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not decode event %d: %v", rec.Seq, err)
+	}
+	return e, nil
+}
+
+func decodeEventData[T Event](data json.RawMessage) (Event, error) {
+	var e T
+	err := json.Unmarshal(data, &e)
+	return e, err
+}
+
+// ApplyEvent changes the state without recording the event. Replays use it
+// to fold a log back into a GameState.
+func (gs *GameState) ApplyEvent(e Event) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	e.apply(gs)
+}
+
+// Rebuild folds a player's event log into a fresh GameState.
+func Rebuild(username string, records []EventRecord) (*GameState, error) {
+	gs := NewGameState(username)
+	for _, rec := range records {
+		e, err := DecodeEvent(rec)
+		if err != nil {
+			return nil, err
+		}
+		gs.ApplyEvent(e)
+	}
+	return gs, nil
+}
+
+func copyMap[K comparable, V any](m map[K]V) map[K]V {
+	copied := map[K]V{}
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
@@ -1,5 +1,10 @@
 package gamelogic
 
+import (
+	"fmt"
+	"sort"
+)
+
 type Player struct {
 	Username string
 	Units    map[int]Unit
@@ -15,8 +20,15 @@ const (
 
 type Unit struct {
 	ID       int
+	Owner    string
 	Rank     UnitRank
 	Location Location
+	HP       int
+}
+
+// Key identifies a unit uniquely across all players.
+func (u Unit) Key() string {
+	return fmt.Sprintf("%s#%d", u.Owner, u.ID)
 }
 
 type ArmyMove struct {
@@ -28,18 +40,12 @@ type ArmyMove struct {
 type RecognitionOfWar struct {
 	Attacker Player
 	Defender Player
+	Mode     CombatMode
+	Seed     int64
 }
 
 type Location string
 
-func getAllRanks() map[UnitRank]struct{} {
-	return map[UnitRank]struct{}{
-		RankInfantry:  {},
-		RankCavalry:   {},
-		RankArtillery: {},
-	}
-}
-
 func getAllLocations() map[Location]struct{} {
 	return map[Location]struct{}{
 		"americas":   {},
@@ -50,3 +56,59 @@ func getAllLocations() map[Location]struct{} {
 		"antarctica": {},
 	}
 }
+
+func getLocationNeighbors() map[Location][]Location {
+	return map[Location][]Location{
+		"americas":   {"europe", "asia", "antarctica"},
+		"europe":     {"americas", "africa", "asia"},
+		"africa":     {"europe", "asia", "antarctica"},
+		"asia":       {"americas", "europe", "africa", "australia"},
+		"australia":  {"asia", "antarctica"},
+		"antarctica": {"americas", "africa", "australia"},
+	}
+}
+
+// GetLocations returns every location on the map in sorted order.
+func GetLocations() []Location {
+	return sortedLocations()
+}
+
+// GetNeighbors returns the locations adjacent to loc in sorted order.
+func GetNeighbors(loc Location) []Location {
+	return sortedNeighbors(loc)
+}
+
+func GetLocationIncome(loc Location) int {
+	return getLocationIncome()[loc]
+}
+
+func sortedNeighbors(loc Location) []Location {
+	neighbors := append([]Location{}, getLocationNeighbors()[loc]...)
+	sort.Slice(neighbors, func(i, j int) bool { return neighbors[i] < neighbors[j] })
+	return neighbors
+}
+
+// locationDistance returns the number of hops between two locations, or -1
+// if either location is unknown.
+func locationDistance(from, to Location) int {
+	neighbors := getLocationNeighbors()
+	if _, ok := neighbors[from]; !ok {
+		return -1
+	}
+	distances := map[Location]int{from: 0}
+	queue := []Location{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == to {
+			return distances[current]
+		}
+		for _, next := range neighbors[current] {
+			if _, seen := distances[next]; !seen {
+				distances[next] = distances[current] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+	return -1
+}
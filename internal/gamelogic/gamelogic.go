@@ -17,31 +17,98 @@ func PrintClientHelp() {
 	fmt.Println("* spawn <location> <rank>")
 	fmt.Println("    example:")
 	fmt.Println("    spawn europe infantry")
+	fmt.Println("    units spawn in territory you own, or anywhere unclaimed while you own none")
 	fmt.Println("* status")
+	fmt.Println("* map")
+	fmt.Println("    territory stays yours after you leave it, until an enemy moves in")
+	fmt.Println("* units")
+	fmt.Println("* endturn")
+	fmt.Println("* ally <username>")
+	fmt.Println("* pact <username>")
+	fmt.Println("* accept <username>")
+	fmt.Println("* break <username>")
+	fmt.Println("* treaties")
+	fmt.Println("* say <message>")
+	fmt.Println("* whisper <username> <message>")
+	fmt.Println("* teamsay <message>")
+	fmt.Println("    goes to the team the server admin put you on")
+	fmt.Println("* history")
+	fmt.Println("* save [file]")
+	fmt.Println("* load [file]")
+	fmt.Println("    loads only while the server has paused the game or after it is over")
 	fmt.Println("* spam <n>")
 	fmt.Println("    example:")
 	fmt.Println("    spam 5")
+	fmt.Println("* config")
 	fmt.Println("* quit")
 	fmt.Println("* help")
 }
 
-func ClientWelcome() (string, error) {
+// ClientWelcome greets the player and asks for their username, unless one
+// was already configured.
+func ClientWelcome(username string) (string, error) {
 	fmt.Println("Welcome to the Peril client!")
+	if username != "" {
+		fmt.Printf("Welcome, %s!\n", username)
+		return username, nil
+	}
 	fmt.Println("Please enter your username:")
 	words := GetInput()
 	if len(words) == 0 {
 		return "", errors.New("you must enter a username. goodbye")
 	}
-	username := words[0]
+	username = words[0]
 	fmt.Printf("Welcome, %s!\n", username)
-	PrintClientHelp()
 	return username, nil
 }
 
 func PrintServerHelp() {
 	fmt.Println("Possible commands:")
-	fmt.Println("* pause")
-	fmt.Println("* resume")
+	fmt.Println("* games")
+	fmt.Println("* create <game>")
+	fmt.Println("* use <game>")
+	fmt.Println("    the commands below apply to the game in use")
+	fmt.Println("* players")
+	fmt.Println("* kick <username> [reason]")
+	fmt.Println("    kicked players are locked out for five minutes")
+	fmt.Println("* ban <username> [reason]")
+	fmt.Println("* unban <username>")
+	fmt.Println("* bans")
+	fmt.Println("* mute <username> <duration> [reason]")
+	fmt.Println("    example:")
+	fmt.Println("    mute alice 10m spamming")
+	fmt.Println("* unmute <username>")
+	fmt.Println("* flags")
+	fmt.Println("* pause [all|<game>|<username>] [duration] [reason]")
+	fmt.Println("    example:")
+	fmt.Println("    pause alice 5m connection trouble")
+	fmt.Println("* resume [all|<game>|<username>]")
+	fmt.Println("* announce [to <username>] <text>")
+	fmt.Println("* team <username> [team]")
+	fmt.Println("    without a team the player is taken off theirs")
+	fmt.Println("* schedule <pause|resume|announce|endgame> <in <duration>|at <time>> [args...]")
+	fmt.Println("    example:")
+	fmt.Println("    schedule pause at 18:00 all 10m maintenance")
+	fmt.Println("    jobs are kept in schedule.json across restarts")
+	fmt.Println("* jobs")
+	fmt.Println("* cancel <job>")
+	fmt.Println("* turns start <sequential|simultaneous> <phase duration> [player] [player]...")
+	fmt.Println("    example:")
+	fmt.Println("    turns start sequential 60s alice bob")
+	fmt.Println("    without players, simultaneous phases end once everyone online has ended them")
+	fmt.Println("    wars are only fought in the combat phase")
+	fmt.Println("* turns skip")
+	fmt.Println("* turns stop")
+	fmt.Println("* victory [eliminate <on|off>|hold <territories> <rounds>|time <duration>|off]")
+	fmt.Println("    example:")
+	fmt.Println("    victory hold 4 3")
+	fmt.Println("    hold counts turn rounds and needs turn mode")
+	fmt.Println("* standings")
+	fmt.Println("* endgame")
+	fmt.Println("* newgame [proportional|dice]")
+	fmt.Println("* save [file]")
+	fmt.Println("* load [file]")
+	fmt.Println("* config")
 	fmt.Println("* quit")
 	fmt.Println("* help")
 }
@@ -77,16 +144,25 @@ func PrintQuit() {
 }
 
 func (gs *GameState) CommandStatus() {
-	if gs.isPaused() {
-		fmt.Println("The game is paused.")
+	if gs.IsPaused() {
+		gs.printPause()
 		return
 	} else {
-		fmt.Println("The game is not paused.")
+		fmt.Fprintln(gs.out, "The game is not paused.")
+	}
+	if err := gs.checkPlaying(); err != nil {
+		fmt.Fprintf(gs.out, "You are spectating: %v.\n", err)
 	}
+	gs.printTurn()
 
 	p := gs.GetPlayerSnap()
-	fmt.Printf("You are %s, and you have %d units.\n", p.Username, len(p.Units))
+	fmt.Fprintf(gs.out, "You are %s, and you have %d units.\n", p.Username, len(p.Units))
+	if gs.GetTurn().Enabled {
+		fmt.Fprintf(gs.out, "Treasury: %d gold, income: %d gold per round.\n", gs.GetTreasury(), gs.GetIncome())
+	} else {
+		fmt.Fprintf(gs.out, "Treasury: %d gold, income: %d gold every %v.\n", gs.GetTreasury(), gs.GetIncome(), IncomeInterval)
+	}
 	for _, unit := range p.Units {
-		fmt.Printf("* %v: %v, %v\n", unit.ID, unit.Location, unit.Rank)
+		fmt.Fprintf(gs.out, "* %v: %v, %v (%d/%d hp)\n", unit.ID, unit.Location, unit.Rank, unitHitPoints(unit), unitMaxHitPoints(unit))
 	}
 }
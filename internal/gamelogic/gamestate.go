@@ -1,68 +1,156 @@
 package gamelogic
 
 import (
+	"io"
+	"os"
+	"sort"
 	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
 )
 
 type GameState struct {
-	Player Player
-	Paused bool
-	mu     *sync.RWMutex
+	GameID      string
+	GameNumber  int
+	Player      Player
+	GamePause   Pause
+	PlayerPause Pause
+	Turn        routing.TurnState
+	Treasury    int
+	Territories map[Location]string
+	Eliminated  bool
+	GameOver    bool
+	CombatMode  CombatMode
+	NextUnitID  int
+	Pacts       map[string]PactType
+	Team        string
+	MutedUntil  time.Time
+	proposals   map[string]PactType
+	proposed    map[string]PactType
+	opponents   map[string]Player
+	events      *EventLog
+	out         io.Writer
+	mu          *sync.RWMutex
 }
 
 func NewGameState(username string) *GameState {
 	return &GameState{
+		GameID:     routing.DefaultGameID,
+		GameNumber: 1,
 		Player: Player{
 			Username: username,
 			Units:    map[int]Unit{},
 		},
-		Paused: false,
-		mu:     &sync.RWMutex{},
+		Treasury:    startingTreasury,
+		Territories: map[Location]string{},
+		CombatMode:  CombatModeProportional,
+		NextUnitID:  1,
+		Pacts:       map[string]PactType{},
+		proposals:   map[string]PactType{},
+		proposed:    map[string]PactType{},
+		opponents:   map[string]Player{},
+		out:         os.Stdout,
+		mu:          &sync.RWMutex{},
 	}
 }
 
-func (gs *GameState) resumeGame() {
-	gs.mu.Lock()
-	defer gs.mu.Unlock()
-	gs.Paused = false
+// SetOutput redirects everything this game state prints, for example to
+// silence a bot. Call it before the state is shared with any handler.
+func (gs *GameState) SetOutput(w io.Writer) {
+	gs.out = w
 }
 
-func (gs *GameState) pauseGame() {
-	gs.mu.Lock()
-	defer gs.mu.Unlock()
-	gs.Paused = true
+func (gs *GameState) GetCombatMode() CombatMode {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return gs.CombatMode
+}
+
+// Pause is one reason the player may not act: either their whole game is
+// paused or the server paused only them.
+type Pause struct {
+	Paused   bool
+	Reason   string
+	ResumeAt time.Time
+}
+
+// active reports whether the pause still holds. A pause with a resume time
+// ends by itself once that time passes, even if the resume message is lost.
+func (p Pause) active() bool {
+	return p.Paused && (p.ResumeAt.IsZero() || time.Now().Before(p.ResumeAt))
 }
 
-func (gs *GameState) isPaused() bool {
+// IsPaused reports whether the player is paused, either with their game or
+// on their own. Play continues only when neither pause holds.
+func (gs *GameState) IsPaused() bool {
 	gs.mu.RLock()
 	defer gs.mu.RUnlock()
-	return gs.Paused
+	return gs.GamePause.active() || gs.PlayerPause.active()
 }
 
-func (gs *GameState) addUnit(u Unit) {
+// setTurn stores the new turn state and reports whether it starts a new round.
+func (gs *GameState) setTurn(ts routing.TurnState) bool {
 	gs.mu.Lock()
 	defer gs.mu.Unlock()
-	gs.Player.Units[u.ID] = u
+	newRound := ts.Enabled && ts.Round != gs.Turn.Round
+	gs.record(TurnChanged{Turn: ts})
+	return newRound
 }
 
-func (gs *GameState) removeUnitsInLocation(loc Location) {
+func (gs *GameState) GetTurn() routing.TurnState {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return gs.Turn
+}
+
+func applyCasualtyToUnits(units map[int]Unit, c UnitCasualty) map[int]Unit {
+	unit, ok := units[c.UnitID]
+	if !ok {
+		return units
+	}
+	if c.Killed {
+		delete(units, c.UnitID)
+		return units
+	}
+	unit.HP = unitHitPoints(unit) - c.Damage
+	if c.RetreatTo != "" {
+		unit.Location = c.RetreatTo
+	}
+	units[c.UnitID] = unit
+	return units
+}
+
+func (gs *GameState) GetUsername() string {
+	return gs.Player.Username
+}
+
+// SetGameID picks the game whose routing keys the player uses. Call it
+// before subscribing.
+func (gs *GameState) SetGameID(id string) {
 	gs.mu.Lock()
 	defer gs.mu.Unlock()
-	for k, v := range gs.Player.Units {
-		if v.Location == loc {
-			delete(gs.Player.Units, k)
-		}
-	}
+	gs.GameID = id
 }
 
-func (gs *GameState) UpdateUnit(u Unit) {
+// SetGameNumber records which round of the game is being played, as the
+// lobby reported it when the player joined.
+func (gs *GameState) SetGameNumber(n int) {
 	gs.mu.Lock()
 	defer gs.mu.Unlock()
-	gs.Player.Units[u.ID] = u
+	gs.GameNumber = n
 }
 
-func (gs *GameState) GetUsername() string {
-	return gs.Player.Username
+func (gs *GameState) GetGameNumber() int {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return gs.GameNumber
+}
+
+func (gs *GameState) GetGameID() string {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return gs.GameID
 }
 
 func (gs *GameState) getUnitsSnap() []Unit {
@@ -82,6 +170,24 @@ func (gs *GameState) GetUnit(id int) (Unit, bool) {
 	return u, ok
 }
 
+func (gs *GameState) GetOpponentsSnap() []Player {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	opponents := []Player{}
+	for _, opponent := range gs.opponents {
+		units := map[int]Unit{}
+		for k, v := range opponent.Units {
+			units[k] = v
+		}
+		opponents = append(opponents, Player{
+			Username: opponent.Username,
+			Units:    units,
+		})
+	}
+	sort.Slice(opponents, func(i, j int) bool { return opponents[i].Username < opponents[j].Username })
+	return opponents
+}
+
 func (gs *GameState) GetPlayerSnap() Player {
 	gs.mu.RLock()
 	defer gs.mu.RUnlock()
@@ -0,0 +1,37 @@
+package gamelogic
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+func PrintLobbyHelp() {
+	fmt.Println("Lobby commands:")
+	fmt.Println("* games")
+	fmt.Println("* create <game>")
+	fmt.Println("* join [game]")
+	fmt.Println("    example:")
+	fmt.Println("    join default")
+	fmt.Println("* quit")
+	fmt.Println("* help")
+}
+
+func PrintGames(games []routing.GameInfo) {
+	if len(games) == 0 {
+		fmt.Println("There are no games yet, create one!")
+		return
+	}
+	fmt.Println("Games:")
+	for _, game := range games {
+		fmt.Printf("* %s: %d player(s)", game.ID, len(game.Players))
+		if len(game.Players) > 0 {
+			fmt.Printf(" (%s)", strings.Join(game.Players, ", "))
+		}
+		if game.Paused {
+			fmt.Print(", paused")
+		}
+		fmt.Println()
+	}
+}
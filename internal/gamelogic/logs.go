@@ -9,9 +9,16 @@ import (
 	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
 )
 
-const logsFile = "game.log"
+var logsFile = "game.log"
 
-const writeToDiskSleep = 1 * time.Second
+var writeToDiskSleep = 1 * time.Second
+
+// SetLogFile changes where WriteLog appends game logs and how long it waits
+// before each write.
+func SetLogFile(path string, delay time.Duration) {
+	logsFile = path
+	writeToDiskSleep = delay
+}
 
 func WriteLog(gamelog routing.GameLog) error {
 	log.Printf("received game log...")
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
 )
 
 type MoveOutcome int
@@ -15,47 +17,80 @@ const (
 )
 
 func (gs *GameState) HandleMove(move ArmyMove) MoveOutcome {
-	defer fmt.Println("------------------------")
+	defer fmt.Fprintln(gs.out, "------------------------")
 	player := gs.GetPlayerSnap()
 
-	fmt.Println()
-	fmt.Println("==== Move Detected ====")
-	fmt.Printf("%s is moving %v unit(s) to %s\n", move.Player.Username, len(move.Units), move.ToLocation)
-	for _, unit := range move.Units {
-		fmt.Printf("* %v\n", unit.Rank)
+	fmt.Fprintln(gs.out)
+	if len(move.Units) == 0 {
+		fmt.Fprintln(gs.out, "==== Enemy Spotted ====")
+		fmt.Fprintf(gs.out, "%s has %v unit(s) within your sight\n", move.Player.Username, len(move.Player.Units))
+	} else {
+		fmt.Fprintln(gs.out, "==== Move Detected ====")
+		fmt.Fprintf(gs.out, "%s is moving %v unit(s) to %s\n", move.Player.Username, len(move.Units), move.ToLocation)
+		for _, unit := range move.Units {
+			fmt.Fprintf(gs.out, "* %v %v\n", unit.Rank, unit.Key())
+		}
 	}
 
 	if player.Username == move.Player.Username {
 		return MoveOutcomeSamePlayer
 	}
+	gs.emit(OpponentSpotted{Player: move.Player})
 
-	overlappingLocation := getOverlappingLocation(player, move.Player)
-	if overlappingLocation != "" {
-		fmt.Printf("You have units in %s! You are at war with %s!\n", overlappingLocation, move.Player.Username)
+	overlappingLocations := OverlappingLocations(player, move.Player)
+	if len(overlappingLocations) > 0 && gs.hasPact(move.Player.Username) {
+		fmt.Fprintf(gs.out, "You share %v with %s, but your treaty keeps the peace.\n", overlappingLocations, move.Player.Username)
+		return MoveOutComeSafe
+	}
+	if len(overlappingLocations) > 0 {
+		fmt.Fprintf(gs.out, "You have units in %v! You are at war with %s!\n", overlappingLocations, move.Player.Username)
+		if ts := gs.GetTurn(); ts.Enabled && ts.Phase != routing.PhaseCombat {
+			fmt.Fprintln(gs.out, "The battle will be fought in the combat phase.")
+		}
 		return MoveOutcomeMakeWar
 	}
-	fmt.Printf("You are safe from %s's units.\n", move.Player.Username)
+	fmt.Fprintf(gs.out, "You are safe from %s's units.\n", move.Player.Username)
 	return MoveOutComeSafe
 }
 
-func getOverlappingLocation(p1 Player, p2 Player) Location {
-	for _, u1 := range p1.Units {
-		for _, u2 := range p2.Units {
-			if u1.Location == u2.Location {
-				return u1.Location
+// OverlappingLocations returns every location both players occupy, in
+// sorted order.
+func OverlappingLocations(p1 Player, p2 Player) []Location {
+	occupied := map[Location]bool{}
+	for _, u2 := range p2.Units {
+		occupied[u2.Location] = true
+	}
+	overlapping := []Location{}
+	for _, loc := range sortedLocations() {
+		if !occupied[loc] {
+			continue
+		}
+		for _, u1 := range p1.Units {
+			if u1.Location == loc {
+				overlapping = append(overlapping, loc)
+				break
 			}
 		}
 	}
-	return ""
+	return overlapping
 }
 
+// CommandMove checks a move and returns it without moving any units. The
+// caller applies it with ApplyMove once it has been published, so a move
+// that never reached the other players is never made locally either.
 func (gs *GameState) CommandMove(words []string) (ArmyMove, error) {
-	if gs.isPaused() {
+	if gs.IsPaused() {
 		return ArmyMove{}, errors.New("the game is paused, you can not move units")
 	}
 	if len(words) < 3 {
 		return ArmyMove{}, errors.New("usage: move <location> <unitID> <unitID> <unitID> etc")
 	}
+	if err := gs.checkPlaying(); err != nil {
+		return ArmyMove{}, err
+	}
+	if err := gs.checkTurn(routing.PhaseMove); err != nil {
+		return ArmyMove{}, err
+	}
 	newLocation := Location(words[1])
 	locations := getAllLocations()
 	if _, ok := locations[newLocation]; !ok {
@@ -77,16 +112,36 @@ func (gs *GameState) CommandMove(words []string) (ArmyMove, error) {
 		if !ok {
 			return ArmyMove{}, fmt.Errorf("error: unit with ID %v not found", unitID)
 		}
-		unit.Location = newLocation
-		gs.UpdateUnit(unit)
+		unitType, ok := GetUnitType(unit.Rank)
+		if !ok {
+			return ArmyMove{}, fmt.Errorf("error: unit with ID %v has unknown rank %s", unitID, unit.Rank)
+		}
+		distance := locationDistance(unit.Location, newLocation)
+		if !unitType.HasAbility(AbilityAmphibious) && (distance < 0 || distance > unitType.Movement) {
+			return ArmyMove{}, fmt.Errorf("error: %s %v can only move %v location(s) from %s", unit.Rank, unitID, unitType.Movement, unit.Location)
+		}
 		newUnits = append(newUnits, unit)
 	}
 
-	mv := ArmyMove{
+	player := gs.GetPlayerSnap()
+	for i := range newUnits {
+		newUnits[i].Location = newLocation
+		player.Units[newUnits[i].ID] = newUnits[i]
+	}
+	return ArmyMove{
 		ToLocation: newLocation,
 		Units:      newUnits,
-		Player:     gs.GetPlayerSnap(),
+		Player:     player,
+	}, nil
+}
+
+// ApplyMove moves the player's units as described by a move from
+// CommandMove.
+func (gs *GameState) ApplyMove(mv ArmyMove) {
+	unitIDs := []int{}
+	for _, unit := range mv.Units {
+		unitIDs = append(unitIDs, unit.ID)
 	}
-	fmt.Printf("Moved %v units to %s\n", len(mv.Units), mv.ToLocation)
-	return mv, nil
+	gs.emit(UnitsMoved{To: mv.ToLocation, UnitIDs: unitIDs})
+	fmt.Fprintf(gs.out, "Moved %v units to %s\n", len(mv.Units), mv.ToLocation)
 }
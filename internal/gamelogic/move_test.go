@@ -0,0 +1,30 @@
+package gamelogic
+
+import (
+	"io"
+	"testing"
+)
+
+// A move only takes effect locally once the caller has published it, so a
+// move that fails to publish leaves the units where everyone else sees them.
+func TestCommandMoveWaitsForApplyMove(t *testing.T) {
+	gs := NewGameState("alice")
+	gs.SetOutput(io.Discard)
+	gs.Player = army("alice", unitAt(1, RankInfantry, "europe"), unitAt(2, RankInfantry, "europe"))
+
+	mv, err := gs.CommandMove([]string{"move", "asia", "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gs.Player.Units[1].Location != "europe" {
+		t.Fatalf("CommandMove() moved unit 1 to %s before the move was applied", gs.Player.Units[1].Location)
+	}
+	if mv.Player.Units[1].Location != "asia" || mv.Player.Units[2].Location != "europe" {
+		t.Errorf("the published army is %v, want unit 1 in asia and unit 2 in europe", mv.Player.Units)
+	}
+
+	gs.ApplyMove(mv)
+	if gs.Player.Units[1].Location != "asia" || gs.Player.Units[2].Location != "europe" {
+		t.Errorf("after ApplyMove() the army is %v", gs.Player.Units)
+	}
+}
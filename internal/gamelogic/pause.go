@@ -2,18 +2,73 @@ package gamelogic
 
 import (
 	"fmt"
+	"io"
+	"time"
 
 	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
 )
 
-func (gs *GameState) HandlePause(ps routing.PlayingState) {
-	defer fmt.Println("------------------------")
-	fmt.Println()
-	if ps.IsPaused {
-		fmt.Println("==== Pause Detected ====")
-		gs.pauseGame()
+// HandlePause applies a pause or resume aimed at this player, their game or
+// everyone, and reports whether it did. Pauses for other players are
+// ignored. A pause on the player alone and a pause on their game are kept
+// apart, so resuming one leaves the other in place.
+func (gs *GameState) HandlePause(ps routing.PlayingState) bool {
+	if ps.Target != "" && ps.Target != routing.PauseTargetAll && ps.Target != gs.GetGameID() && ps.Target != gs.GetUsername() {
+		return false
+	}
+	player := ps.Target == gs.GetUsername()
+	defer fmt.Fprintln(gs.out, "------------------------")
+	fmt.Fprintln(gs.out)
+	if !ps.IsPaused {
+		fmt.Fprintln(gs.out, "==== Resume Detected ====")
+		gs.emit(PauseChanged{Paused: false, Player: player})
+		if gs.IsPaused() {
+			gs.printPause()
+		}
+		return true
+	}
+
+	fmt.Fprintln(gs.out, "==== Pause Detected ====")
+	gs.emit(PauseChanged{
+		Paused:   true,
+		Player:   player,
+		Reason:   ps.Reason,
+		ResumeAt: ps.ResumeAt,
+	})
+	if player {
+		fmt.Fprintln(gs.out, "Only you have been paused.")
+	}
+	if ps.Reason != "" {
+		fmt.Fprintf(gs.out, "Reason: %s\n", ps.Reason)
+	}
+	if !ps.ResumeAt.IsZero() {
+		fmt.Fprintf(gs.out, "Play resumes automatically in %v.\n", time.Until(ps.ResumeAt).Round(time.Second))
+	}
+	return true
+}
+
+// printPause describes the pauses that still hold, for the status command
+// and after a resume that leaves the player paused.
+func (gs *GameState) printPause() {
+	gs.mu.RLock()
+	game := gs.GamePause
+	player := gs.PlayerPause
+	gs.mu.RUnlock()
+	if game.active() {
+		printPauseState(gs.out, "The game is paused", game)
+	}
+	if player.active() {
+		printPauseState(gs.out, "You are paused", player)
+	}
+}
+
+func printPauseState(w io.Writer, what string, p Pause) {
+	if p.Reason == "" {
+		fmt.Fprintf(w, "%s.\n", what)
 	} else {
-		fmt.Println("==== Resume Detected ====")
-		gs.resumeGame()
+		fmt.Fprintf(w, "%s: %s\n", what, p.Reason)
+	}
+	if !p.ResumeAt.IsZero() {
+		fmt.Fprintf(w, "Play resumes in %v.\n", time.Until(p.ResumeAt).Round(time.Second))
 	}
 }
@@ -0,0 +1,46 @@
+package gamelogic
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+func TestHandlePause(t *testing.T) {
+	pause := func(target string) routing.PlayingState {
+		return routing.PlayingState{IsPaused: true, Target: target}
+	}
+	resume := func(target string) routing.PlayingState {
+		return routing.PlayingState{Target: target}
+	}
+	tests := []struct {
+		name   string
+		states []routing.PlayingState
+		paused bool
+	}{
+		{"game pause", []routing.PlayingState{pause("default")}, true},
+		{"pause on everyone", []routing.PlayingState{pause(routing.PauseTargetAll)}, true},
+		{"player pause", []routing.PlayingState{pause("alice")}, true},
+		{"another player's pause", []routing.PlayingState{pause("bob")}, false},
+		{"another game's pause", []routing.PlayingState{pause("other")}, false},
+		{"game resume keeps the player paused", []routing.PlayingState{pause("alice"), pause("default"), resume("default")}, true},
+		{"player resume keeps the game paused", []routing.PlayingState{pause("default"), pause("alice"), resume("alice")}, true},
+		{"resume everyone keeps the player paused", []routing.PlayingState{pause("alice"), resume(routing.PauseTargetAll)}, true},
+		{"both resumed", []routing.PlayingState{pause("default"), pause("alice"), resume("alice"), resume(routing.PauseTargetAll)}, false},
+		{"timed pause that ran out", []routing.PlayingState{{IsPaused: true, Target: "alice", ResumeAt: time.Now().Add(-time.Second)}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := NewGameState("alice")
+			gs.SetOutput(io.Discard)
+			for _, ps := range tt.states {
+				gs.HandlePause(ps)
+			}
+			if got := gs.IsPaused(); got != tt.paused {
+				t.Errorf("IsPaused() = %v, want %v", got, tt.paused)
+			}
+		})
+	}
+}
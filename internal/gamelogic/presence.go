@@ -0,0 +1,31 @@
+package gamelogic
+
+import (
+	"fmt"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+// HandlePresence announces other players joining and leaving and reports
+// whether anything was printed.
+func (gs *GameState) HandlePresence(p routing.Presence) bool {
+	if p.Username == gs.GetUsername() {
+		return false
+	}
+	defer fmt.Fprintln(gs.out, "------------------------")
+	fmt.Fprintln(gs.out)
+	switch p.Status {
+	case routing.PresenceJoin:
+		fmt.Fprintln(gs.out, "==== Player Joined ====")
+		fmt.Fprintf(gs.out, "%s joined the game.\n", p.Username)
+	case routing.PresenceLeave:
+		fmt.Fprintln(gs.out, "==== Player Left ====")
+		fmt.Fprintf(gs.out, "%s left the game.\n", p.Username)
+	case routing.PresenceDrop:
+		fmt.Fprintln(gs.out, "==== Player Dropped ====")
+		fmt.Fprintf(gs.out, "%s stopped responding and was dropped.\n", p.Username)
+	default:
+		fmt.Fprintf(gs.out, "%s is %s.\n", p.Username, p.Status)
+	}
+	return true
+}
@@ -0,0 +1,182 @@
+package gamelogic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+// SnapshotVersion is bumped whenever Snapshot changes shape. Older snapshots
+// are upgraded in Restore and newer ones are rejected. Version 2 added the
+// game ID and number.
+const SnapshotVersion = 2
+
+const SaveDir = "saves"
+
+type Snapshot struct {
+	Version     int
+	SavedAt     time.Time
+	GameID      string
+	GameNumber  int
+	Player      Player
+	Treasury    int
+	Territories map[Location]string
+	Eliminated  bool
+	GameOver    bool
+	CombatMode  CombatMode
+	NextUnitID  int
+	Pacts       map[string]PactType
+	Team        string
+	Opponents   map[string]Player
+}
+
+// SnapshotPath is where a player's save for a game is kept. Saves are
+// grouped by game so that playing in one game never overwrites another.
+func SnapshotPath(gameID, username string) string {
+	return filepath.Join(SaveDir, gameID, username+".json")
+}
+
+func (gs *GameState) Snapshot() Snapshot {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	territories := map[Location]string{}
+	for k, v := range gs.Territories {
+		territories[k] = v
+	}
+	pacts := map[string]PactType{}
+	for k, v := range gs.Pacts {
+		pacts[k] = v
+	}
+	opponents := map[string]Player{}
+	for k, v := range gs.opponents {
+		opponents[k] = v
+	}
+	units := map[int]Unit{}
+	for k, v := range gs.Player.Units {
+		units[k] = v
+	}
+	return Snapshot{
+		Version:    SnapshotVersion,
+		SavedAt:    time.Now(),
+		GameID:     gs.GameID,
+		GameNumber: gs.GameNumber,
+		Player: Player{
+			Username: gs.Player.Username,
+			Units:    units,
+		},
+		Treasury:    gs.Treasury,
+		Territories: territories,
+		Eliminated:  gs.Eliminated,
+		GameOver:    gs.GameOver,
+		CombatMode:  gs.CombatMode,
+		NextUnitID:  gs.NextUnitID,
+		Pacts:       pacts,
+		Team:        gs.Team,
+		Opponents:   opponents,
+	}
+}
+
+// Restore replaces the game state with a snapshot saved by the same player
+// in the same game. Snapshots from before version 2 do not record their
+// game and are taken to be from the first game of the default game.
+func (gs *GameState) Restore(s Snapshot) error {
+	if s.Version > SnapshotVersion {
+		return fmt.Errorf("snapshot version %d is newer than the supported version %d", s.Version, SnapshotVersion)
+	}
+	if s.Version < 2 {
+		s.GameID = routing.DefaultGameID
+		s.GameNumber = 1
+	}
+	if s.Player.Username != gs.GetUsername() {
+		return fmt.Errorf("snapshot belongs to %s", s.Player.Username)
+	}
+	gameID, gameNumber := gs.GetGameID(), gs.GetGameNumber()
+	if s.GameID != gameID {
+		return fmt.Errorf("snapshot is from game %s, not %s", s.GameID, gameID)
+	}
+	if s.GameNumber != gameNumber {
+		return fmt.Errorf("snapshot is from game #%d, but game #%d is being played", s.GameNumber, gameNumber)
+	}
+
+	gs.emit(SnapshotRestored{Snapshot: s})
+	return nil
+}
+
+func (gs *GameState) SaveToFile(path string) error {
+	return WriteSnapshotFile(path, gs.Snapshot())
+}
+
+func (gs *GameState) LoadFromFile(path string) error {
+	var s Snapshot
+	err := ReadSnapshotFile(path, &s)
+	if err != nil {
+		return err
+	}
+	return gs.Restore(s)
+}
+
+// WriteSnapshotFile writes v as JSON, replacing path atomically so a crash
+// never leaves a half written save behind.
+func WriteSnapshotFile(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode snapshot: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("could not create save directory: %v", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("could not write snapshot: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+func ReadSnapshotFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("could not decode snapshot: %v", err)
+	}
+	return nil
+}
+
+func (gs *GameState) CommandSave(words []string) error {
+	path := SnapshotPath(gs.GetGameID(), gs.GetUsername())
+	if len(words) > 1 {
+		path = words[1]
+	}
+	if err := gs.SaveToFile(path); err != nil {
+		return err
+	}
+	fmt.Fprintf(gs.out, "Saved your game to %s\n", path)
+	return nil
+}
+
+// CommandLoad restores a save in the middle of a session. Other players
+// would see armies appear out of nowhere, so it is only allowed while the
+// server has paused the whole game or once the game is over.
+func (gs *GameState) CommandLoad(words []string) error {
+	gs.mu.RLock()
+	allowed := gs.GamePause.active() || gs.GameOver
+	gs.mu.RUnlock()
+	if !allowed {
+		return errors.New("you can only load a save while the server has paused the game or after it is over")
+	}
+	path := SnapshotPath(gs.GetGameID(), gs.GetUsername())
+	if len(words) > 1 {
+		path = words[1]
+	}
+	if err := gs.LoadFromFile(path); err != nil {
+		return err
+	}
+	fmt.Fprintf(gs.out, "Loaded your game from %s\n", path)
+	return nil
+}
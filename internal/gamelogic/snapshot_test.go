@@ -0,0 +1,74 @@
+package gamelogic
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+func TestRestoreChecksGame(t *testing.T) {
+	saved := NewGameState("alice")
+	saved.SetGameID("europe")
+	saved.SetGameNumber(3)
+	snapshot := saved.Snapshot()
+
+	tests := []struct {
+		name       string
+		username   string
+		gameID     string
+		gameNumber int
+		snapshot   Snapshot
+		wantErr    string
+	}{
+		{"same game", "alice", "europe", 3, snapshot, ""},
+		{"another player", "bob", "europe", 3, snapshot, "belongs to alice"},
+		{"another game", "alice", "asia", 3, snapshot, "from game europe"},
+		{"an earlier round", "alice", "europe", 4, snapshot, "from game #3"},
+		{"version 1 in the default game", "alice", routing.DefaultGameID, 1, Snapshot{Version: 1, Player: Player{Username: "alice"}}, ""},
+		{"version 1 elsewhere", "alice", "europe", 1, Snapshot{Version: 1, Player: Player{Username: "alice"}}, "from game default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := NewGameState(tt.username)
+			gs.SetGameID(tt.gameID)
+			gs.SetGameNumber(tt.gameNumber)
+			err := gs.Restore(tt.snapshot)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Restore() error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Restore() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCommandLoadOnlyWhenNotLive(t *testing.T) {
+	gs := NewGameState("alice")
+	gs.SetOutput(io.Discard)
+	path := filepath.Join(t.TempDir(), "alice.json")
+	if err := gs.SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+	load := []string{"load", path}
+
+	if err := gs.CommandLoad(load); err == nil {
+		t.Error("loaded a save while the game was live")
+	}
+	gs.HandlePause(routing.PlayingState{IsPaused: true, Target: "alice"})
+	if err := gs.CommandLoad(load); err == nil {
+		t.Error("loaded a save while only the player was paused")
+	}
+	gs.HandlePause(routing.PlayingState{IsPaused: true})
+	if err := gs.CommandLoad(load); err != nil {
+		t.Errorf("could not load while the game was paused: %v", err)
+	}
+	gs.HandlePause(routing.PlayingState{})
+	gs.HandleGameOver(routing.GameOver{})
+	if err := gs.CommandLoad(load); err != nil {
+		t.Errorf("could not load after the game was over: %v", err)
+	}
+}
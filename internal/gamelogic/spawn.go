@@ -3,12 +3,20 @@ package gamelogic
 import (
 	"errors"
 	"fmt"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
 )
 
 func (gs *GameState) CommandSpawn(words []string) error {
 	if len(words) < 3 {
 		return errors.New("usage: spawn <location> <rank>")
 	}
+	if err := gs.checkPlaying(); err != nil {
+		return err
+	}
+	if err := gs.checkTurn(routing.PhaseReinforce); err != nil {
+		return err
+	}
 
 	locationName := words[1]
 	locations := getAllLocations()
@@ -17,18 +25,49 @@ func (gs *GameState) CommandSpawn(words []string) error {
 	}
 
 	rank := words[2]
-	units := getAllRanks()
-	if _, ok := units[UnitRank(rank)]; !ok {
+	unitType, ok := GetUnitType(UnitRank(rank))
+	if !ok {
 		return fmt.Errorf("error: %s is not a valid unit", rank)
 	}
 
-	id := len(gs.getUnitsSnap()) + 1
-	gs.addUnit(Unit{
-		ID:       id,
+	if err := gs.checkSpawnLocation(Location(locationName)); err != nil {
+		return err
+	}
+
+	unit, err := gs.spawnUnit(Unit{
+		Owner:    gs.GetUsername(),
 		Rank:     UnitRank(rank),
 		Location: Location(locationName),
-	})
+		HP:       unitType.HitPoints,
+	}, unitType.Cost)
+	if err != nil {
+		return fmt.Errorf("error: can not afford a(n) %s: %v", rank, err)
+	}
+
+	fmt.Fprintf(gs.out, "Spawned a(n) %s in %s with id %v for %d gold\n", rank, locationName, unit.ID, unitType.Cost)
+	return nil
+}
 
-	fmt.Printf("Spawned a(n) %s in %s with id %v\n", rank, locationName, id)
+// checkSpawnLocation enforces where new units may appear. Units are raised
+// in territory the player owns. A player who owns no territory, at the start
+// of a game or after losing all of it, may instead land in any location
+// nobody owns, which is how they gain a foothold.
+func (gs *GameState) checkSpawnLocation(loc Location) error {
+	if owner, ok := gs.GetTerritoriesSnap()[loc]; ok && owner != gs.GetUsername() {
+		return fmt.Errorf("error: %s is controlled by %s", loc, owner)
+	}
+	owned := gs.ownedTerritories()
+	if len(owned) > 0 && !containsLocation(owned, loc) {
+		return fmt.Errorf("error: you can only spawn units in territories you own: %v", owned)
+	}
 	return nil
 }
+
+func containsLocation(locations []Location, loc Location) bool {
+	for _, l := range locations {
+		if l == loc {
+			return true
+		}
+	}
+	return false
+}
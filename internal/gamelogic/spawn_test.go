@@ -0,0 +1,48 @@
+package gamelogic
+
+import "testing"
+
+func TestCheckSpawnLocation(t *testing.T) {
+	tests := []struct {
+		name        string
+		territories map[Location]string
+		loc         Location
+		wantErr     bool
+	}{
+		{"no territory, unclaimed", map[Location]string{}, "asia", false},
+		{"no territory, enemy owned", map[Location]string{"asia": "bob"}, "asia", true},
+		{"owns territory, own location", map[Location]string{"europe": "alice"}, "europe", false},
+		{"owns territory, unclaimed", map[Location]string{"europe": "alice"}, "asia", true},
+		{"owns territory, enemy owned", map[Location]string{"europe": "alice", "asia": "bob"}, "asia", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := NewGameState("alice")
+			gs.Territories = tt.territories
+			err := gs.checkSpawnLocation(tt.loc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkSpawnLocation(%s) = %v, want error: %v", tt.loc, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCommandSpawn(t *testing.T) {
+	gs := NewGameState("alice")
+	gs.Treasury = 6
+	if err := gs.CommandSpawn([]string{"spawn", "europe", "cavalry"}); err != nil {
+		t.Fatalf("spawning cavalry: %v", err)
+	}
+	if gs.Treasury != 1 {
+		t.Errorf("treasury after spawning = %d, want 1", gs.Treasury)
+	}
+	if len(gs.Player.Units) != 1 || gs.Player.Units[1].HP != 2 {
+		t.Errorf("units after spawning = %v, want one cavalry with 2 HP", gs.Player.Units)
+	}
+	if err := gs.CommandSpawn([]string{"spawn", "europe", "cavalry"}); err == nil {
+		t.Error("spawning cavalry with 1 gold succeeded")
+	}
+	if err := gs.CommandSpawn([]string{"spawn", "europe", "dragon"}); err == nil {
+		t.Error("spawning an unknown rank succeeded")
+	}
+}
@@ -0,0 +1,139 @@
+package gamelogic
+
+import (
+	"fmt"
+	"sort"
+)
+
+type TerritoryChange struct {
+	Location      Location
+	Owner         string
+	PreviousOwner string
+}
+
+// UpdateTerritories applies the territory rules from the player's side and
+// returns the changes to broadcast:
+//   - a location is claimed when the player has units there, no enemy does,
+//     and nobody on their side owns it yet;
+//   - an owned location stays owned while it is empty, so moving every unit
+//     out does not give it up and it keeps paying income;
+//   - an owned location is lost once enemies stand in it and the player has
+//     no units left there.
+func (gs *GameState) UpdateTerritories() []TerritoryChange {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	mine := map[Location]bool{}
+	for _, unit := range gs.Player.Units {
+		mine[unit.Location] = true
+	}
+	hostile := map[Location]bool{}
+	for _, opponent := range gs.opponents {
+		if gs.Pacts[opponent.Username] == PactAlliance {
+			continue
+		}
+		for _, unit := range opponent.Units {
+			hostile[unit.Location] = true
+		}
+	}
+
+	changes := []TerritoryChange{}
+	for _, loc := range sortedLocations() {
+		owner := gs.Territories[loc]
+		allyOwned := owner != "" && gs.Pacts[owner] == PactAlliance
+		if mine[loc] && !hostile[loc] && owner != gs.Player.Username && !allyOwned {
+			changes = append(changes, TerritoryChange{
+				Location:      loc,
+				Owner:         gs.Player.Username,
+				PreviousOwner: owner,
+			})
+		} else if owner == gs.Player.Username && !mine[loc] && hostile[loc] {
+			changes = append(changes, TerritoryChange{
+				Location:      loc,
+				PreviousOwner: owner,
+			})
+		}
+	}
+	for _, tc := range changes {
+		gs.record(TerritoryChanged{Change: tc})
+	}
+	return changes
+}
+
+// HandleTerritoryChange applies another player's ownership change and
+// reports whether anything was printed.
+func (gs *GameState) HandleTerritoryChange(tc TerritoryChange) bool {
+	if tc.Owner == gs.GetUsername() || tc.PreviousOwner == gs.GetUsername() && tc.Owner == "" {
+		return false
+	}
+	defer fmt.Fprintln(gs.out, "------------------------")
+	fmt.Fprintln(gs.out)
+	fmt.Fprintln(gs.out, "==== Territory Update ====")
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	current := gs.Territories[tc.Location]
+	if tc.Owner == "" {
+		if current != tc.PreviousOwner {
+			fmt.Fprintf(gs.out, "%s no longer holds %s.\n", tc.PreviousOwner, tc.Location)
+			return true
+		}
+		gs.record(TerritoryChanged{Change: tc})
+		fmt.Fprintf(gs.out, "%s has lost control of %s.\n", tc.PreviousOwner, tc.Location)
+		return true
+	}
+	gs.record(TerritoryChanged{Change: tc})
+	if current == gs.Player.Username {
+		fmt.Fprintf(gs.out, "%s has taken %s from you!\n", tc.Owner, tc.Location)
+		return true
+	}
+	fmt.Fprintf(gs.out, "%s now controls %s.\n", tc.Owner, tc.Location)
+	return true
+}
+
+func (gs *GameState) CommandMap() {
+	territories := gs.GetTerritoriesSnap()
+	fmt.Fprintln(gs.out, "Territories:")
+	for _, loc := range sortedLocations() {
+		owner, ok := territories[loc]
+		if !ok {
+			fmt.Fprintf(gs.out, "* %s: unclaimed\n", loc)
+		} else if owner == gs.GetUsername() {
+			fmt.Fprintf(gs.out, "* %s: %s (you)\n", loc, owner)
+		} else {
+			fmt.Fprintf(gs.out, "* %s: %s\n", loc, owner)
+		}
+	}
+}
+
+func (gs *GameState) GetTerritoriesSnap() map[Location]string {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	territories := map[Location]string{}
+	for k, v := range gs.Territories {
+		territories[k] = v
+	}
+	return territories
+}
+
+// ownedTerritories returns the locations the player currently controls.
+func (gs *GameState) ownedTerritories() []Location {
+	username := gs.GetUsername()
+	territories := gs.GetTerritoriesSnap()
+	locations := []Location{}
+	for _, loc := range sortedLocations() {
+		if territories[loc] == username {
+			locations = append(locations, loc)
+		}
+	}
+	return locations
+}
+
+func sortedLocations() []Location {
+	locations := []Location{}
+	for loc := range getAllLocations() {
+		locations = append(locations, loc)
+	}
+	sort.Slice(locations, func(i, j int) bool { return locations[i] < locations[j] })
+	return locations
+}
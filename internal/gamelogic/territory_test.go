@@ -0,0 +1,116 @@
+package gamelogic
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUpdateTerritories(t *testing.T) {
+	tests := []struct {
+		name    string
+		owner   string
+		mine    bool
+		enemy   bool
+		ally    bool
+		want    []TerritoryChange
+		wantMap string
+	}{
+		{
+			name:    "claim an empty location",
+			mine:    true,
+			want:    []TerritoryChange{{Location: "asia", Owner: "alice"}},
+			wantMap: "alice",
+		},
+		{
+			name:    "take an undefended enemy location",
+			owner:   "bob",
+			mine:    true,
+			want:    []TerritoryChange{{Location: "asia", Owner: "alice", PreviousOwner: "bob"}},
+			wantMap: "alice",
+		},
+		{
+			name:    "contested location is not claimed",
+			mine:    true,
+			enemy:   true,
+			want:    []TerritoryChange{},
+			wantMap: "",
+		},
+		{
+			name:    "leaving keeps ownership",
+			owner:   "alice",
+			want:    []TerritoryChange{},
+			wantMap: "alice",
+		},
+		{
+			name:    "enemy in an abandoned location takes it",
+			owner:   "alice",
+			enemy:   true,
+			want:    []TerritoryChange{{Location: "asia", PreviousOwner: "alice"}},
+			wantMap: "",
+		},
+		{
+			name:    "holding against an enemy keeps ownership",
+			owner:   "alice",
+			mine:    true,
+			enemy:   true,
+			want:    []TerritoryChange{},
+			wantMap: "alice",
+		},
+		{
+			name:    "ally's territory is not taken",
+			owner:   "carol",
+			mine:    true,
+			ally:    true,
+			want:    []TerritoryChange{},
+			wantMap: "carol",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := NewGameState("alice")
+			if tt.owner != "" {
+				gs.Territories["asia"] = tt.owner
+			}
+			if tt.mine {
+				gs.Player.Units[1] = Unit{ID: 1, Owner: "alice", Rank: RankInfantry, Location: "asia"}
+			}
+			if tt.enemy {
+				gs.opponents["bob"] = Player{Username: "bob", Units: map[int]Unit{
+					1: {ID: 1, Owner: "bob", Rank: RankInfantry, Location: "asia"},
+				}}
+			}
+			if tt.ally {
+				gs.Pacts["carol"] = PactAlliance
+			}
+
+			got := gs.UpdateTerritories()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UpdateTerritories() = %v, want %v", got, tt.want)
+			}
+			if owner := gs.Territories["asia"]; owner != tt.wantMap {
+				t.Errorf("asia is owned by %q, want %q", owner, tt.wantMap)
+			}
+		})
+	}
+}
+
+func TestHandleTerritoryChange(t *testing.T) {
+	gs := NewGameState("alice")
+	gs.Territories["asia"] = "alice"
+
+	gs.HandleTerritoryChange(TerritoryChange{Location: "asia", Owner: "bob", PreviousOwner: "alice"})
+	if owner := gs.Territories["asia"]; owner != "bob" {
+		t.Fatalf("asia is owned by %q after bob took it", owner)
+	}
+
+	// A stale loss from a previous owner must not clear the new owner.
+	gs.HandleTerritoryChange(TerritoryChange{Location: "asia", PreviousOwner: "carol"})
+	if owner := gs.Territories["asia"]; owner != "bob" {
+		t.Errorf("asia is owned by %q after a stale loss", owner)
+	}
+
+	gs.HandleTerritoryChange(TerritoryChange{Location: "asia", PreviousOwner: "bob"})
+	if owner, ok := gs.Territories["asia"]; ok {
+		t.Errorf("asia is still owned by %q after bob lost it", owner)
+	}
+}
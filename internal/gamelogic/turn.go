@@ -0,0 +1,74 @@
+package gamelogic
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+func (gs *GameState) HandleTurn(ts routing.TurnState) {
+	defer fmt.Fprintln(gs.out, "------------------------")
+	fmt.Fprintln(gs.out)
+	newRound := gs.setTurn(ts)
+	if !ts.Enabled {
+		fmt.Fprintln(gs.out, "==== Turn Mode Disabled ====")
+		fmt.Fprintln(gs.out, "Everyone may move at any time.")
+		return
+	}
+	fmt.Fprintf(gs.out, "==== Round %d: %s phase ====\n", ts.Round, ts.Phase)
+	if newRound {
+		income := gs.CollectIncome()
+		fmt.Fprintf(gs.out, "You collected %d gold and now have %d.\n", income, gs.GetTreasury())
+	}
+	if ts.ActivePlayer == "" {
+		fmt.Fprintln(gs.out, "All players give orders simultaneously.")
+	} else if ts.ActivePlayer == gs.GetUsername() {
+		fmt.Fprintln(gs.out, "It is your turn!")
+	} else {
+		fmt.Fprintf(gs.out, "It is %s's turn.\n", ts.ActivePlayer)
+	}
+	if !ts.Deadline.IsZero() {
+		fmt.Fprintf(gs.out, "The phase ends in %v.\n", time.Until(ts.Deadline).Round(time.Second))
+	}
+}
+
+// checkTurn returns an error explaining why the player may not act in the
+// given phase right now. It always succeeds when turn mode is disabled.
+func (gs *GameState) checkTurn(phase string) error {
+	ts := gs.GetTurn()
+	if !ts.Enabled {
+		return nil
+	}
+	if ts.ActivePlayer != "" && ts.ActivePlayer != gs.GetUsername() {
+		return fmt.Errorf("it is not your turn: waiting for %s (%s phase)", ts.ActivePlayer, ts.Phase)
+	}
+	if ts.Phase != phase {
+		return fmt.Errorf("you can only do that during the %s phase, it is currently the %s phase", phase, ts.Phase)
+	}
+	return nil
+}
+
+func (gs *GameState) printTurn() {
+	ts := gs.GetTurn()
+	if !ts.Enabled {
+		return
+	}
+	fmt.Fprintf(gs.out, "Round %d, %s phase", ts.Round, ts.Phase)
+	if ts.ActivePlayer != "" {
+		fmt.Fprintf(gs.out, ", %s's turn", ts.ActivePlayer)
+	}
+	if !ts.Deadline.IsZero() {
+		fmt.Fprintf(gs.out, ", %v left", time.Until(ts.Deadline).Round(time.Second))
+	}
+	fmt.Fprintln(gs.out, ".")
+}
+
+func (gs *GameState) GetTurnEnd() routing.TurnEnd {
+	ts := gs.GetTurn()
+	return routing.TurnEnd{
+		Username: gs.GetUsername(),
+		Round:    ts.Round,
+		Phase:    ts.Phase,
+	}
+}
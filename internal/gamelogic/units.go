@@ -0,0 +1,212 @@
+package gamelogic
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+const UnitCatalogFile = "units.json"
+
+type Ability string
+
+const (
+	// AbilityAmphibious lets a unit move to any location regardless of its movement range.
+	AbilityAmphibious Ability = "amphibious"
+)
+
+type UnitType struct {
+	Rank      UnitRank  `json:"rank"`
+	Attack    int       `json:"attack"`
+	Defense   int       `json:"defense"`
+	HitPoints int       `json:"hit_points"`
+	Movement  int       `json:"movement"`
+	Cost      int       `json:"cost"`
+	Abilities []Ability `json:"abilities,omitempty"`
+}
+
+func (ut UnitType) HasAbility(a Ability) bool {
+	for _, ability := range ut.Abilities {
+		if ability == a {
+			return true
+		}
+	}
+	return false
+}
+
+// unitCatalog is shared by every player in the process, which for bots
+// means several goroutines, so it is guarded.
+var unitCatalog = struct {
+	sync.RWMutex
+	types map[UnitRank]UnitType
+}{types: defaultUnitCatalog()}
+
+func defaultUnitCatalog() map[UnitRank]UnitType {
+	return map[UnitRank]UnitType{
+		RankInfantry: {
+			Rank:      RankInfantry,
+			Attack:    1,
+			Defense:   1,
+			HitPoints: 1,
+			Movement:  1,
+			Cost:      1,
+		},
+		RankCavalry: {
+			Rank:      RankCavalry,
+			Attack:    5,
+			Defense:   5,
+			HitPoints: 2,
+			Movement:  2,
+			Cost:      5,
+		},
+		RankArtillery: {
+			Rank:      RankArtillery,
+			Attack:    10,
+			Defense:   10,
+			HitPoints: 3,
+			Movement:  1,
+			Cost:      10,
+		},
+	}
+}
+
+// LoadUnitCatalog reads a JSON list of unit types from path. Listed ranks
+// replace the defaults and unknown ranks are added to the catalog. Only the
+// server and the offline simulator load a catalog; players use the one the
+// server sends when they join.
+func LoadUnitCatalog(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var types []UnitType
+	err = json.Unmarshal(data, &types)
+	if err != nil {
+		return fmt.Errorf("could not parse unit catalog: %v", err)
+	}
+	return setUnitTypes(types)
+}
+
+// UnitCatalog returns the catalog in use, to send to players.
+func UnitCatalog() []routing.UnitType {
+	catalog := []routing.UnitType{}
+	for _, ut := range GetUnitTypes() {
+		abilities := []string{}
+		for _, a := range ut.Abilities {
+			abilities = append(abilities, string(a))
+		}
+		catalog = append(catalog, routing.UnitType{
+			Rank:      string(ut.Rank),
+			Attack:    ut.Attack,
+			Defense:   ut.Defense,
+			HitPoints: ut.HitPoints,
+			Movement:  ut.Movement,
+			Cost:      ut.Cost,
+			Abilities: abilities,
+		})
+	}
+	return catalog
+}
+
+// SetUnitCatalog replaces the catalog in use with the one the server sent.
+func SetUnitCatalog(catalog []routing.UnitType) error {
+	types := []UnitType{}
+	for _, ut := range catalog {
+		abilities := []Ability{}
+		for _, a := range ut.Abilities {
+			abilities = append(abilities, Ability(a))
+		}
+		types = append(types, UnitType{
+			Rank:      UnitRank(ut.Rank),
+			Attack:    ut.Attack,
+			Defense:   ut.Defense,
+			HitPoints: ut.HitPoints,
+			Movement:  ut.Movement,
+			Cost:      ut.Cost,
+			Abilities: abilities,
+		})
+	}
+	return setUnitTypes(types)
+}
+
+// setUnitTypes checks the listed types and puts them in place of the
+// defaults, adding unknown ranks.
+func setUnitTypes(types []UnitType) error {
+	catalog := defaultUnitCatalog()
+	for _, ut := range types {
+		if ut.Rank == "" {
+			return fmt.Errorf("unit type is missing a rank")
+		}
+		if ut.HitPoints < 1 || ut.Movement < 0 || ut.Cost < 0 {
+			return fmt.Errorf("unit type %s has invalid stats", ut.Rank)
+		}
+		catalog[ut.Rank] = ut
+	}
+	unitCatalog.Lock()
+	defer unitCatalog.Unlock()
+	unitCatalog.types = catalog
+	return nil
+}
+
+func GetUnitType(rank UnitRank) (UnitType, bool) {
+	unitCatalog.RLock()
+	defer unitCatalog.RUnlock()
+	ut, ok := unitCatalog.types[rank]
+	return ut, ok
+}
+
+func GetUnitTypes() []UnitType {
+	unitCatalog.RLock()
+	types := []UnitType{}
+	for _, ut := range unitCatalog.types {
+		types = append(types, ut)
+	}
+	unitCatalog.RUnlock()
+	sort.Slice(types, func(i, j int) bool {
+		if types[i].Cost != types[j].Cost {
+			return types[i].Cost < types[j].Cost
+		}
+		return types[i].Rank < types[j].Rank
+	})
+	return types
+}
+
+func unitMaxHitPoints(u Unit) int {
+	if ut, ok := GetUnitType(u.Rank); ok {
+		return ut.HitPoints
+	}
+	return 1
+}
+
+// unitHitPoints returns the unit's remaining hit points. Units that carry no
+// hit points are treated as unhurt.
+func unitHitPoints(u Unit) int {
+	if u.HP <= 0 {
+		return unitMaxHitPoints(u)
+	}
+	return u.HP
+}
+
+func unitsToHitPoints(units []Unit) int {
+	hp := 0
+	for _, unit := range units {
+		hp += unitHitPoints(unit)
+	}
+	return hp
+}
+
+func PrintUnitTypes() {
+	fmt.Println("Unit types:")
+	for _, ut := range GetUnitTypes() {
+		fmt.Printf("* %s: attack %d, defense %d, hp %d, movement %d, cost %d", ut.Rank, ut.Attack, ut.Defense, ut.HitPoints, ut.Movement, ut.Cost)
+		if len(ut.Abilities) > 0 {
+			fmt.Printf(", abilities %v", ut.Abilities)
+		}
+		fmt.Println()
+	}
+}
@@ -0,0 +1,35 @@
+package gamelogic
+
+import (
+	"testing"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+func TestSetUnitCatalog(t *testing.T) {
+	t.Cleanup(func() { SetUnitCatalog(nil) })
+
+	catalog := UnitCatalog()
+	for i := range catalog {
+		if catalog[i].Rank == string(RankCavalry) {
+			catalog[i].Attack = 7
+		}
+	}
+	catalog = append(catalog, routing.UnitType{Rank: "marines", Attack: 2, Defense: 2, HitPoints: 1, Cost: 3, Abilities: []string{string(AbilityAmphibious)}})
+	if err := SetUnitCatalog(catalog); err != nil {
+		t.Fatal(err)
+	}
+	if ut, _ := GetUnitType(RankCavalry); ut.Attack != 7 {
+		t.Errorf("cavalry attack = %d, want the server's 7", ut.Attack)
+	}
+	if ut, ok := GetUnitType("marines"); !ok || !ut.HasAbility(AbilityAmphibious) {
+		t.Errorf("marines = %+v, %v, want the server's amphibious unit", ut, ok)
+	}
+
+	if err := SetUnitCatalog([]routing.UnitType{{Rank: "ghost", HitPoints: 0}}); err == nil {
+		t.Error("SetUnitCatalog() accepted a unit with no hit points")
+	}
+	if _, ok := GetUnitType("marines"); !ok {
+		t.Error("a bad catalog replaced the one in use")
+	}
+}
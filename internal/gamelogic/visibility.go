@@ -0,0 +1,68 @@
+package gamelogic
+
+// VisibleLocations returns the locations a player can see: every location
+// they have units in and every location adjacent to one.
+func VisibleLocations(p Player) map[Location]bool {
+	neighbors := getLocationNeighbors()
+	visible := map[Location]bool{}
+	for _, unit := range p.Units {
+		visible[unit.Location] = true
+		for _, loc := range neighbors[unit.Location] {
+			visible[loc] = true
+		}
+	}
+	return visible
+}
+
+// FilterPlayerFor returns p with only the units the viewer can see.
+func FilterPlayerFor(p Player, viewer Player) Player {
+	visible := VisibleLocations(viewer)
+	units := map[int]Unit{}
+	for id, unit := range p.Units {
+		if visible[unit.Location] {
+			units[id] = unit
+		}
+	}
+	return Player{
+		Username: p.Username,
+		Units:    units,
+	}
+}
+
+// FilterMoveFor returns the part of a move the viewer can observe. It
+// returns false when none of the mover's units are visible.
+func FilterMoveFor(move ArmyMove, viewer Player) (ArmyMove, bool) {
+	visible := VisibleLocations(viewer)
+	player := FilterPlayerFor(move.Player, viewer)
+	if len(player.Units) == 0 {
+		return ArmyMove{}, false
+	}
+	moved := []Unit{}
+	for _, unit := range move.Units {
+		if visible[unit.Location] {
+			moved = append(moved, unit)
+		}
+	}
+	return ArmyMove{
+		Player:     player,
+		Units:      moved,
+		ToLocation: move.ToLocation,
+	}, true
+}
+
+// FilterWarResultFor returns the battles of a war the viewer can observe. It
+// returns false when none of them were fought where the viewer can see.
+func FilterWarResultFor(wr WarResult, viewer Player) (WarResult, bool) {
+	visible := VisibleLocations(viewer)
+	battles := []Battle{}
+	for _, battle := range wr.Battles {
+		if visible[battle.Location] {
+			battles = append(battles, battle)
+		}
+	}
+	if len(battles) == 0 {
+		return WarResult{}, false
+	}
+	wr.Battles = battles
+	return wr, true
+}
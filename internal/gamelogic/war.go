@@ -14,91 +14,274 @@ const (
 	WarOutcomeDraw
 )
 
-func (gs *GameState) HandleWar(rw RecognitionOfWar) (outcome WarOutcome, winner string, loser string) {
-	defer fmt.Println("------------------------")
-	fmt.Println()
-	fmt.Println("==== War Declared ====")
-	fmt.Printf("%s has declared war on %s!\n", rw.Attacker.Username, rw.Defender.Username)
+type Battle struct {
+	Location      Location
+	Winner        string
+	AttackerPower int
+	DefenderPower int
+	Casualties    []UnitCasualty
+}
+
+// WarResult is published by the player who resolved a war. The server
+// passes it on to the defender and to every player who can see one of the
+// battles, so that they apply the same casualties.
+type WarResult struct {
+	Attacker string
+	Defender string
+	Mode     CombatMode
+	Seed     int64
+	Battles  []Battle
+}
+
+func (gs *GameState) HandleWar(rw RecognitionOfWar) (WarOutcome, WarResult) {
+	defer fmt.Fprintln(gs.out, "------------------------")
+	fmt.Fprintln(gs.out)
+	fmt.Fprintln(gs.out, "==== War Declared ====")
+	fmt.Fprintf(gs.out, "%s has declared war on %s!\n", rw.Attacker.Username, rw.Defender.Username)
 
 	player := gs.GetPlayerSnap()
 
 	if player.Username == rw.Defender.Username {
-		fmt.Printf("%s, you published the war.\n", player.Username)
-		return WarOutcomeNotInvolved, "", ""
+		fmt.Fprintf(gs.out, "%s, you published the war.\n", player.Username)
+		return WarOutcomeNotInvolved, WarResult{}
 	}
 
 	if player.Username != rw.Attacker.Username {
-		fmt.Printf("%s, you are not involved in this war.\n", player.Username)
-		return WarOutcomeNotInvolved, "", ""
+		fmt.Fprintf(gs.out, "%s, you are not involved in this war.\n", player.Username)
+		return WarOutcomeNotInvolved, WarResult{}
 	}
 
-	overlappingLocation := getOverlappingLocation(rw.Attacker, rw.Defender)
-	if overlappingLocation == "" {
-		fmt.Printf("Error! No units are in the same location. No war will be fought.\n")
-		return WarOutcomeNoUnits, "", ""
+	result, ok := ResolveWar(rw)
+	if !ok {
+		fmt.Fprintf(gs.out, "Error! No units are in the same location. No war will be fought.\n")
+		return WarOutcomeNoUnits, WarResult{}
 	}
 
-	attackerUnits := []Unit{}
-	defenderUnits := []Unit{}
-	for _, unit := range rw.Attacker.Units {
-		if unit.Location == overlappingLocation {
-			attackerUnits = append(attackerUnits, unit)
+	fmt.Fprintf(gs.out, "The battle is fought in %s combat mode.\n", combatModeOrDefault(rw.Mode))
+	gs.printWarResult(result)
+	gs.applyWarResult(result)
+
+	won, lost := 0, 0
+	for _, battle := range result.Battles {
+		if battle.Winner == player.Username {
+			won++
+		} else if battle.Winner != "" {
+			lost++
 		}
 	}
-	for _, unit := range rw.Defender.Units {
-		if unit.Location == overlappingLocation {
-			defenderUnits = append(defenderUnits, unit)
+	fmt.Fprintf(gs.out, "You won %d and lost %d of %d battle(s).\n", won, lost, len(result.Battles))
+	if won > lost {
+		fmt.Fprintf(gs.out, "%s has won the war!\n", player.Username)
+		return WarOutcomeYouWon, result
+	} else if lost > won {
+		fmt.Fprintf(gs.out, "%s has won the war!\n", rw.Defender.Username)
+		fmt.Fprintln(gs.out, "You have lost the war!")
+		return WarOutcomeOpponentWon, result
+	}
+	fmt.Fprintln(gs.out, "The war ended in a draw!")
+	return WarOutcomeDraw, result
+}
+
+// HandleWarResult applies a war resolved by another player and reports
+// whether the local player was involved.
+func (gs *GameState) HandleWarResult(wr WarResult) bool {
+	username := gs.GetUsername()
+	if wr.Attacker == username {
+		return false
+	}
+	gs.applyWarResult(wr)
+	if wr.Defender != username {
+		return false
+	}
+
+	defer fmt.Fprintln(gs.out, "------------------------")
+	fmt.Fprintln(gs.out)
+	fmt.Fprintln(gs.out, "==== War Result ====")
+	fmt.Fprintf(gs.out, "%s fought your units in %s combat mode.\n", wr.Attacker, combatModeOrDefault(wr.Mode))
+	gs.printWarResult(wr)
+	return true
+}
+
+// ResolveWar fights a battle in every location the attacker and defender
+// share, in location order. Each battle uses its own seed derived from the
+// war's seed. It returns false when their units do not share a location.
+func ResolveWar(rw RecognitionOfWar) (WarResult, bool) {
+	overlappingLocations := OverlappingLocations(rw.Attacker, rw.Defender)
+	if len(overlappingLocations) == 0 {
+		return WarResult{}, false
+	}
+
+	battles := []Battle{}
+	for i, loc := range overlappingLocations {
+		battles = append(battles, resolveBattleIn(rw, loc, rw.Seed+int64(i)))
+	}
+	return WarResult{
+		Attacker: rw.Attacker.Username,
+		Defender: rw.Defender.Username,
+		Mode:     combatModeOrDefault(rw.Mode),
+		Seed:     rw.Seed,
+		Battles:  battles,
+	}, true
+}
+
+func resolveBattleIn(rw RecognitionOfWar, overlappingLocation Location, seed int64) Battle {
+	attackerUnits := unitsInLocation(rw.Attacker, overlappingLocation)
+	defenderUnits := unitsInLocation(rw.Defender, overlappingLocation)
+	br := ResolveBattle(rw.Mode, attackerUnits, defenderUnits, seed)
+
+	battle := Battle{
+		Location:      overlappingLocation,
+		AttackerPower: br.AttackerPower,
+		DefenderPower: br.DefenderPower,
+	}
+	attackerCasualties := setCasualtyOwner(br.AttackerCasualties, rw.Attacker.Username)
+	defenderCasualties := setCasualtyOwner(br.DefenderCasualties, rw.Defender.Username)
+	switch br.Winner {
+	case BattleAttackerWon:
+		battle.Winner = rw.Attacker.Username
+		defenderCasualties = retreat(rw.Defender, defenderUnits, defenderCasualties, rw.Attacker, overlappingLocation)
+	case BattleDefenderWon:
+		battle.Winner = rw.Defender.Username
+		attackerCasualties = retreat(rw.Attacker, attackerUnits, attackerCasualties, rw.Defender, overlappingLocation)
+	}
+	battle.Casualties = append(attackerCasualties, defenderCasualties...)
+	return battle
+}
+
+// retreat sends the loser's surviving units to a neighbouring location the
+// winner does not occupy, preferring one the loser already holds. Survivors
+// with nowhere to go are captured.
+func retreat(loser Player, units []Unit, casualties []UnitCasualty, winner Player, from Location) []UnitCasualty {
+	blocked := map[Location]bool{}
+	for _, unit := range winner.Units {
+		blocked[unit.Location] = true
+	}
+	held := map[Location]bool{}
+	for _, unit := range loser.Units {
+		held[unit.Location] = true
+	}
+	to := Location("")
+	for _, loc := range sortedNeighbors(from) {
+		if blocked[loc] {
+			continue
+		}
+		if to == "" || held[loc] && !held[to] {
+			to = loc
 		}
 	}
 
-	fmt.Printf("%s's units:\n", rw.Attacker.Username)
-	for _, unit := range attackerUnits {
-		fmt.Printf("  * %v\n", unit.Rank)
-	}
-	fmt.Printf("%s's units:\n", rw.Defender.Username)
-	for _, unit := range defenderUnits {
-		fmt.Printf("  * %v\n", unit.Rank)
-	}
-	attackerPower := unitsToPowerLevel(attackerUnits)
-	defenderPower := unitsToPowerLevel(defenderUnits)
-	fmt.Printf("Attacker has a power level of %v\n", attackerPower)
-	fmt.Printf("Defender has a power level of %v\n", defenderPower)
-	if attackerPower > defenderPower {
-		fmt.Printf("%s has won the war!\n", rw.Attacker.Username)
-		if player.Username == rw.Defender.Username {
-			fmt.Println("You have lost the war!")
-			gs.removeUnitsInLocation(overlappingLocation)
-			fmt.Printf("Your units in %s have been killed.\n", overlappingLocation)
-			return WarOutcomeOpponentWon, rw.Attacker.Username, rw.Defender.Username
+	byID := map[int]int{}
+	for i, casualty := range casualties {
+		byID[casualty.UnitID] = i
+	}
+	for _, unit := range units {
+		i, ok := byID[unit.ID]
+		if ok && casualties[i].Killed {
+			continue
+		}
+		if !ok {
+			casualties = append(casualties, UnitCasualty{
+				Owner:  loser.Username,
+				UnitID: unit.ID,
+				Rank:   unit.Rank,
+			})
+			i = len(casualties) - 1
 		}
-		return WarOutcomeYouWon, rw.Attacker.Username, rw.Defender.Username
-	} else if defenderPower > attackerPower {
-		fmt.Printf("%s has won the war!\n", rw.Defender.Username)
-		if player.Username == rw.Attacker.Username {
-			fmt.Println("You have lost the war!")
-			gs.removeUnitsInLocation(overlappingLocation)
-			fmt.Printf("Your units in %s have been killed.\n", overlappingLocation)
-			return WarOutcomeOpponentWon, rw.Defender.Username, rw.Attacker.Username
+		if to == "" {
+			casualties[i].Damage = unitHitPoints(unit)
+			casualties[i].Killed = true
+		} else {
+			casualties[i].RetreatTo = to
 		}
-		return WarOutcomeYouWon, rw.Defender.Username, rw.Attacker.Username
 	}
-	fmt.Println("The war ended in a draw!")
-	fmt.Printf("Your units in %s have been killed.\n", overlappingLocation)
-	gs.removeUnitsInLocation(overlappingLocation)
-	return WarOutcomeDraw, rw.Attacker.Username, rw.Defender.Username
+	return casualties
 }
 
-func unitsToPowerLevel(units []Unit) int {
+func (gs *GameState) applyWarResult(wr WarResult) {
+	for _, battle := range wr.Battles {
+		for _, casualty := range battle.Casualties {
+			gs.emit(UnitCasualtyApplied{Casualty: casualty})
+		}
+	}
+}
+
+func (gs *GameState) printWarResult(wr WarResult) {
+	for _, battle := range wr.Battles {
+		fmt.Fprintf(gs.out, "Battle of %s:\n", battle.Location)
+		fmt.Fprintf(gs.out, "  %s had a power level of %v\n", wr.Attacker, battle.AttackerPower)
+		fmt.Fprintf(gs.out, "  %s had a power level of %v\n", wr.Defender, battle.DefenderPower)
+		for _, casualty := range battle.Casualties {
+			fmt.Fprintf(gs.out, "  * %s's %s %v", casualty.Owner, casualty.Rank, casualty.UnitID)
+			if casualty.Killed {
+				fmt.Fprint(gs.out, " was killed")
+			} else if casualty.Damage > 0 {
+				fmt.Fprintf(gs.out, " took %d damage", casualty.Damage)
+			}
+			if casualty.RetreatTo != "" {
+				if casualty.Damage > 0 {
+					fmt.Fprint(gs.out, " and")
+				}
+				fmt.Fprintf(gs.out, " retreated to %s", casualty.RetreatTo)
+			}
+			fmt.Fprintln(gs.out)
+		}
+		if battle.Winner == "" {
+			fmt.Fprintln(gs.out, "  The battle was a draw.")
+		} else {
+			fmt.Fprintf(gs.out, "  %s won the battle.\n", battle.Winner)
+		}
+	}
+}
+
+func setCasualtyOwner(casualties []UnitCasualty, owner string) []UnitCasualty {
+	for i := range casualties {
+		casualties[i].Owner = owner
+	}
+	return casualties
+}
+
+func unitsInLocation(p Player, loc Location) []Unit {
+	units := []Unit{}
+	for _, unit := range p.Units {
+		if unit.Location == loc {
+			units = append(units, unit)
+		}
+	}
+	return units
+}
+
+func combatModeOrDefault(mode CombatMode) CombatMode {
+	if mode == "" || mode == combatModeLegacyProportional {
+		return CombatModeProportional
+	}
+	return mode
+}
+
+// AttackPower is the combined attack of units, scaled by their remaining hit points.
+func AttackPower(units []Unit) int {
+	return unitsToAttackPower(units)
+}
+
+// DefensePower is the combined defense of units, scaled by their remaining hit points.
+func DefensePower(units []Unit) int {
+	return unitsToDefensePower(units)
+}
+
+func unitsToAttackPower(units []Unit) int {
 	power := 0
 	for _, unit := range units {
-		if unit.Rank == RankArtillery {
-			power += 10
+		if unitType, ok := GetUnitType(unit.Rank); ok {
+			power += unitType.Attack * unitHitPoints(unit) / unitType.HitPoints
 		}
-		if unit.Rank == RankCavalry {
-			power += 5
-		}
-		if unit.Rank == RankInfantry {
-			power += 1
+	}
+	return power
+}
+
+func unitsToDefensePower(units []Unit) int {
+	power := 0
+	for _, unit := range units {
+		if unitType, ok := GetUnitType(unit.Rank); ok {
+			power += unitType.Defense * unitHitPoints(unit) / unitType.HitPoints
 		}
 	}
 	return power
@@ -0,0 +1,149 @@
+package gamelogic
+
+import (
+	"reflect"
+	"testing"
+)
+
+func army(username string, units ...Unit) Player {
+	p := Player{Username: username, Units: map[int]Unit{}}
+	for _, u := range units {
+		u.Owner = username
+		p.Units[u.ID] = u
+	}
+	return p
+}
+
+func unitAt(id int, rank UnitRank, loc Location) Unit {
+	return Unit{ID: id, Rank: rank, Location: loc}
+}
+
+func TestResolveWarBattles(t *testing.T) {
+	attacker := army("alice", unitAt(1, RankInfantry, "asia"), unitAt(2, RankInfantry, "europe"), unitAt(3, RankInfantry, "africa"))
+	defender := army("bob", unitAt(1, RankInfantry, "europe"), unitAt(2, RankInfantry, "asia"))
+
+	result, ok := ResolveWar(RecognitionOfWar{Attacker: attacker, Defender: defender, Mode: CombatModeDice, Seed: 7})
+	if !ok {
+		t.Fatal("ResolveWar found no shared location")
+	}
+	locations := []Location{}
+	for _, battle := range result.Battles {
+		locations = append(locations, battle.Location)
+	}
+	if want := []Location{"asia", "europe"}; !reflect.DeepEqual(locations, want) {
+		t.Errorf("battles were fought in %v, want %v", locations, want)
+	}
+
+	again, _ := ResolveWar(RecognitionOfWar{Attacker: attacker, Defender: defender, Mode: CombatModeDice, Seed: 7})
+	if !reflect.DeepEqual(result, again) {
+		t.Errorf("the same war resolved differently: %+v and %+v", result, again)
+	}
+
+	_, ok = ResolveWar(RecognitionOfWar{Attacker: army("alice", unitAt(1, RankInfantry, "asia")), Defender: army("bob", unitAt(1, RankInfantry, "europe"))})
+	if ok {
+		t.Error("ResolveWar fought a war without a shared location")
+	}
+}
+
+func TestResolveWarRetreat(t *testing.T) {
+	tests := []struct {
+		name     string
+		attacker Player
+		defender Player
+		want     UnitCasualty
+	}{
+		{
+			name:     "survivor retreats to a location it holds",
+			attacker: army("alice", unitAt(1, RankCavalry, "australia"), unitAt(2, RankCavalry, "australia"), unitAt(3, RankCavalry, "australia")),
+			defender: army("bob", unitAt(1, RankCavalry, "australia"), unitAt(2, RankCavalry, "australia"), unitAt(3, RankInfantry, "asia")),
+			want:     UnitCasualty{Owner: "bob", UnitID: 2, Rank: RankCavalry, Damage: 1, RetreatTo: "asia"},
+		},
+		{
+			name:     "survivor with nowhere to go is captured",
+			attacker: army("alice", unitAt(1, RankCavalry, "australia"), unitAt(2, RankCavalry, "australia"), unitAt(3, RankCavalry, "australia"), unitAt(4, RankInfantry, "asia"), unitAt(5, RankInfantry, "antarctica")),
+			defender: army("bob", unitAt(1, RankCavalry, "australia"), unitAt(2, RankCavalry, "australia")),
+			want:     UnitCasualty{Owner: "bob", UnitID: 2, Rank: RankCavalry, Damage: 2, Killed: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, ok := ResolveWar(RecognitionOfWar{Attacker: tt.attacker, Defender: tt.defender, Mode: CombatModeProportional})
+			if !ok || len(result.Battles) != 1 {
+				t.Fatalf("ResolveWar() = %+v, want one battle", result)
+			}
+			battle := result.Battles[0]
+			if battle.Winner != "alice" {
+				t.Fatalf("battle won by %q, want alice", battle.Winner)
+			}
+			for _, casualty := range battle.Casualties {
+				if casualty.Owner == "bob" && casualty.UnitID == tt.want.UnitID {
+					if casualty != tt.want {
+						t.Errorf("casualty = %+v, want %+v", casualty, tt.want)
+					}
+					return
+				}
+			}
+			t.Errorf("no casualty for bob's unit %d in %+v", tt.want.UnitID, battle.Casualties)
+		})
+	}
+}
+
+// Both players must end up with the same view of the war: the attacker
+// resolves it and the defender applies the published result.
+func TestWarResultAppliesOnBothSides(t *testing.T) {
+	attacker := army("alice", unitAt(1, RankCavalry, "asia"), unitAt(2, RankInfantry, "asia"), unitAt(3, RankInfantry, "europe"))
+	defender := army("bob", unitAt(1, RankInfantry, "asia"), unitAt(2, RankCavalry, "europe"))
+
+	alice := NewGameState("alice")
+	alice.Player = attacker
+	alice.opponents["bob"] = defender
+	bob := NewGameState("bob")
+	bob.Player = defender
+	bob.opponents["alice"] = attacker
+
+	outcome, result := alice.HandleWar(RecognitionOfWar{Attacker: attacker, Defender: defender, Mode: CombatModeDice, Seed: 3})
+	if outcome == WarOutcomeNotInvolved || outcome == WarOutcomeNoUnits {
+		t.Fatalf("HandleWar() outcome = %v", outcome)
+	}
+	if !bob.HandleWarResult(result) {
+		t.Fatal("the defender was not told about the war")
+	}
+
+	if !reflect.DeepEqual(alice.Player.Units, bob.opponents["alice"].Units) {
+		t.Errorf("alice's army is %v, bob sees %v", alice.Player.Units, bob.opponents["alice"].Units)
+	}
+	if !reflect.DeepEqual(bob.Player.Units, alice.opponents["bob"].Units) {
+		t.Errorf("bob's army is %v, alice sees %v", bob.Player.Units, alice.opponents["bob"].Units)
+	}
+}
+
+func TestHandleWarNotInvolved(t *testing.T) {
+	gs := NewGameState("carol")
+	rw := RecognitionOfWar{
+		Attacker: army("alice", unitAt(1, RankInfantry, "asia")),
+		Defender: army("bob", unitAt(1, RankInfantry, "asia")),
+	}
+	if outcome, _ := gs.HandleWar(rw); outcome != WarOutcomeNotInvolved {
+		t.Errorf("a bystander got outcome %v", outcome)
+	}
+}
+
+func TestFilterWarResultFor(t *testing.T) {
+	wr := WarResult{
+		Attacker: "alice",
+		Defender: "bob",
+		Battles:  []Battle{{Location: "australia", Winner: "alice"}, {Location: "europe", Winner: "bob"}},
+	}
+
+	visible, ok := FilterWarResultFor(wr, army("carol", unitAt(1, RankInfantry, "africa")))
+	if !ok {
+		t.Fatal("carol next to europe saw none of the war")
+	}
+	if len(visible.Battles) != 1 || visible.Battles[0].Location != "europe" {
+		t.Errorf("carol saw battles %+v, want only europe", visible.Battles)
+	}
+
+	if _, ok := FilterWarResultFor(wr, army("dave")); ok {
+		t.Error("dave with no units saw the war")
+	}
+}
@@ -5,12 +5,34 @@ import (
 	"context"
 	"encoding/gob"
 	"encoding/json"
+	"errors"
 	"log"
 
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/ratelimit"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
+var ErrRateLimited = errors.New("publishing too quickly, message dropped")
+
+var publishLimiter *ratelimit.Limiter
+
+// SetPublishLimiter throttles PublishJSON and PublishGob with a bucket per
+// routing key. It is off until set, and nil turns it off again.
+func SetPublishLimiter(l *ratelimit.Limiter) {
+	publishLimiter = l
+}
+
+func allowPublish(key string) error {
+	if publishLimiter != nil && !publishLimiter.Allow(key) {
+		return ErrRateLimited
+	}
+	return nil
+}
+
 func PublishJSON[T any](ch *amqp.Channel, exchange, key string, val T) error {
+	if err := allowPublish(key); err != nil {
+		return err
+	}
 	data, err := json.Marshal(val)
 	if err != nil {
 		log.Printf("Error marshalling JSON: %s", err)
@@ -19,6 +41,7 @@ func PublishJSON[T any](ch *amqp.Channel, exchange, key string, val T) error {
 
 	publishError := ch.PublishWithContext(context.Background(), exchange, key, false, false, amqp.Publishing{
 		ContentType: "application/json",
+		Headers:     signatureHeaders(ch, key, data),
 		Body:        data,
 	})
 	if publishError != nil {
@@ -30,6 +53,9 @@ func PublishJSON[T any](ch *amqp.Channel, exchange, key string, val T) error {
 }
 
 func PublishGob[T any](ch *amqp.Channel, exchange, key string, val T) error {
+	if err := allowPublish(key); err != nil {
+		return err
+	}
 	var encByte bytes.Buffer
 	enc := gob.NewEncoder(&encByte)
 	err := enc.Encode(val)
@@ -40,6 +66,7 @@ func PublishGob[T any](ch *amqp.Channel, exchange, key string, val T) error {
 
 	publishError := ch.PublishWithContext(context.Background(), exchange, key, false, false, amqp.Publishing{
 		ContentType: "application/gob",
+		Headers:     signatureHeaders(ch, key, encByte.Bytes()),
 		Body:        encByte.Bytes(),
 	})
 	if publishError != nil {
@@ -0,0 +1,115 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ServeJSON answers requests published to key. Each reply is sent straight
+// to the caller's ReplyTo queue with the request's CorrelationId.
+func ServeJSON[Req, Resp any](conn *amqp.Connection, exchange, queueName, key string, queueType SimpleQueueType, handler func(Req) Resp) error {
+	channel, boundQueue, binderr := DeclareAndBind(conn, exchange, queueName, key, queueType)
+	if binderr != nil {
+		return binderr
+	}
+
+	msg, err := channel.Consume(boundQueue.Name, "", false, false, false, false, nil)
+	if err != nil {
+		log.Printf("Error consuming message from MQ: %v", err)
+		return err
+	}
+
+	go func() {
+		for request := range msg {
+			var singleMsg Req
+			err := json.Unmarshal(request.Body, &singleMsg)
+			if err != nil {
+				log.Printf("Error decoding request from MQ: %v", err)
+				request.Nack(false, false)
+				continue
+			}
+
+			response := handler(singleMsg)
+			if request.ReplyTo != "" {
+				err = replyJSON(channel, request, response)
+				if err != nil {
+					log.Printf("Error replying to %s: %v", request.ReplyTo, err)
+				}
+			}
+			request.Ack(false)
+		}
+	}()
+
+	return nil
+}
+
+func replyJSON[T any](ch *amqp.Channel, request amqp.Delivery, val T) error {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return ch.PublishWithContext(context.Background(), "", request.ReplyTo, false, false, amqp.Publishing{
+		ContentType:   "application/json",
+		CorrelationId: request.CorrelationId,
+		Body:          data,
+	})
+}
+
+// CallJSON publishes req to key and waits for the matching reply on a
+// private, temporary queue.
+func CallJSON[Req, Resp any](conn *amqp.Connection, exchange, key string, req Req, timeout time.Duration) (Resp, error) {
+	var resp Resp
+	ch, err := conn.Channel()
+	if err != nil {
+		return resp, err
+	}
+	defer ch.Close()
+
+	replyQueue, err := ch.QueueDeclare("", false, true, true, false, nil)
+	if err != nil {
+		return resp, fmt.Errorf("could not declare reply queue: %v", err)
+	}
+	replies, err := ch.Consume(replyQueue.Name, "", true, true, false, false, nil)
+	if err != nil {
+		return resp, fmt.Errorf("could not consume replies: %v", err)
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return resp, err
+	}
+	correlationID := fmt.Sprintf("%016x", rand.Uint64())
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err = ch.PublishWithContext(ctx, exchange, key, false, false, amqp.Publishing{
+		ContentType:   "application/json",
+		CorrelationId: correlationID,
+		ReplyTo:       replyQueue.Name,
+		Body:          data,
+	})
+	if err != nil {
+		return resp, err
+	}
+
+	for {
+		select {
+		case msg, ok := <-replies:
+			if !ok {
+				return resp, fmt.Errorf("reply queue for %s closed", key)
+			}
+			if msg.CorrelationId != correlationID {
+				continue
+			}
+			err := json.Unmarshal(msg.Body, &resp)
+			return resp, err
+		case <-ctx.Done():
+			return resp, fmt.Errorf("no reply to %s within %v", key, timeout)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package pubsub
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// SignatureHeader carries an HMAC of a message's routing key and body, keyed
+// by the publisher's sign in token. Other players can read the header but
+// cannot forge it, so the server can check that a message published to
+// alice's key was sent by alice.
+const SignatureHeader = "x-peril-signature"
+
+// Verifier reports whether signature was made by the player who published
+// to key.
+type Verifier func(key string, body []byte, signature string) bool
+
+var signers = struct {
+	sync.Mutex
+	tokens map[*amqp.Channel]string
+}{tokens: map[*amqp.Channel]string{}}
+
+// SignWith signs everything later published on ch with token. Each player
+// needs their own channel, which lets one process run several bots.
+func SignWith(ch *amqp.Channel, token string) {
+	signers.Lock()
+	defer signers.Unlock()
+	signers.tokens[ch] = token
+}
+
+// Sign returns the signature of a message published to key.
+func Sign(token, key string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write([]byte(key))
+	mac.Write([]byte{0})
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidSignature reports whether signature was made with token.
+func ValidSignature(token, key string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(token, key, body)), []byte(signature))
+}
+
+// signatureHeaders returns the headers for a message published on ch, or nil
+// when ch does not sign.
+func signatureHeaders(ch *amqp.Channel, key string, body []byte) amqp.Table {
+	signers.Lock()
+	token, ok := signers.tokens[ch]
+	signers.Unlock()
+	if !ok {
+		return nil
+	}
+	return amqp.Table{SignatureHeader: Sign(token, key, body)}
+}
+
+// verified reports whether msg may be handed to a subscriber. Every message
+// passes when there is no verifier.
+func verified(verify Verifier, msg amqp.Delivery) bool {
+	if verify == nil {
+		return true
+	}
+	signature, _ := msg.Headers[SignatureHeader].(string)
+	return signature != "" && verify(msg.RoutingKey, msg.Body, signature)
+}
@@ -0,0 +1,28 @@
+package pubsub
+
+import "testing"
+
+func TestValidSignature(t *testing.T) {
+	body := []byte(`{"ToLocation":"asia"}`)
+	signature := Sign("alice-token", "default.army_moves.alice", body)
+
+	tests := []struct {
+		name  string
+		token string
+		key   string
+		body  []byte
+		want  bool
+	}{
+		{"same message", "alice-token", "default.army_moves.alice", body, true},
+		{"someone else's token", "bob-token", "default.army_moves.alice", body, false},
+		{"replayed on another key", "alice-token", "default.army_moves.bob", body, false},
+		{"changed body", "alice-token", "default.army_moves.alice", []byte(`{"ToLocation":"europe"}`), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidSignature(tt.token, tt.key, tt.body, signature); got != tt.want {
+				t.Errorf("ValidSignature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
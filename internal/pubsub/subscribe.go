@@ -17,30 +17,65 @@ const (
 	NackDiscard AckType = "nackdiscard"
 )
 
+var prefetch = 10
+
+// SetPrefetch sets how many messages each new subscription may hold
+// without acknowledging them.
+func SetPrefetch(n int) {
+	prefetch = n
+}
+
 func SubscribeJSON[T any](conn *amqp.Connection, exchange, queueName, key string, queueType SimpleQueueType, handler func(T) AckType) error {
+	return SubscribeJSONWithKey(conn, exchange, queueName, key, queueType, ignoreKey(handler))
+}
+
+// SubscribeJSONWithKey also hands the handler the routing key each message
+// was published with, which tells it who sent the message.
+func SubscribeJSONWithKey[T any](conn *amqp.Connection, exchange, queueName, key string, queueType SimpleQueueType, handler func(string, T) AckType) error {
+	return SubscribeJSONVerified(conn, exchange, queueName, key, queueType, nil, handler)
+}
+
+// SubscribeJSONVerified discards every message that verify rejects before
+// it reaches the handler, so the routing key can be trusted to name the
+// sender.
+func SubscribeJSONVerified[T any](conn *amqp.Connection, exchange, queueName, key string, queueType SimpleQueueType, verify Verifier, handler func(string, T) AckType) error {
 	channel, boundQueue, binderr := DeclareAndBind(conn, exchange, queueName, key, queueType)
 	if binderr != nil {
 		log.Fatalf("Error binding to channel and queue: %s", binderr)
 	}
 
+	err := channel.Qos(prefetch, 0, false)
+	if err != nil {
+		log.Printf("Error setting channel parameters: %v", err)
+		return err
+	}
+
 	msg, err := channel.Consume(boundQueue.Name, "", false, false, false, false, nil)
 	if err != nil {
 		log.Printf("Error consuming message from MQ: %v", err)
 		return err
 	}
 
-	go workerJSONRoutine(msg, handler)
+	go workerJSONRoutine(msg, verify, handler)
 
 	return nil
 }
 
 func SubscribeGob[T any](conn *amqp.Connection, exchange, queueName, key string, queueType SimpleQueueType, handler func(T) AckType) error {
+	return SubscribeGobWithKey(conn, exchange, queueName, key, queueType, ignoreKey(handler))
+}
+
+func SubscribeGobWithKey[T any](conn *amqp.Connection, exchange, queueName, key string, queueType SimpleQueueType, handler func(string, T) AckType) error {
+	return SubscribeGobVerified(conn, exchange, queueName, key, queueType, nil, handler)
+}
+
+func SubscribeGobVerified[T any](conn *amqp.Connection, exchange, queueName, key string, queueType SimpleQueueType, verify Verifier, handler func(string, T) AckType) error {
 	channel, boundQueue, binderr := DeclareAndBind(conn, exchange, queueName, key, queueType)
 	if binderr != nil {
 		log.Fatalf("Error binding to channel and queue: %s", binderr)
 	}
 
-	err := channel.Qos(10, 0, false)
+	err := channel.Qos(prefetch, 0, false)
 	if err != nil {
 		log.Printf("Error setting channel parameters: %v", err)
 		return err
@@ -52,13 +87,24 @@ func SubscribeGob[T any](conn *amqp.Connection, exchange, queueName, key string,
 		return err
 	}
 
-	go workerGobRoutine(msg, handler)
+	go workerGobRoutine(msg, verify, handler)
 
 	return nil
 }
 
-func workerJSONRoutine[T any](messages <-chan amqp.Delivery, handler func(T) AckType) {
+func ignoreKey[T any](handler func(T) AckType) func(string, T) AckType {
+	return func(_ string, val T) AckType {
+		return handler(val)
+	}
+}
+
+func workerJSONRoutine[T any](messages <-chan amqp.Delivery, verify Verifier, handler func(string, T) AckType) {
 	for msg := range messages {
+		if !verified(verify, msg) {
+			log.Printf("Dropping unsigned or forged message on %s", msg.RoutingKey)
+			msg.Nack(false, false)
+			continue
+		}
 		var singleMsg T
 		messageResult := json.Unmarshal(msg.Body, &singleMsg)
 		if messageResult != nil {
@@ -66,7 +112,7 @@ func workerJSONRoutine[T any](messages <-chan amqp.Delivery, handler func(T) Ack
 			return
 		}
 
-		ackType := handler(singleMsg)
+		ackType := handler(msg.RoutingKey, singleMsg)
 
 		if ackType == Ack {
 			msg.Ack(false)
@@ -79,8 +125,13 @@ func workerJSONRoutine[T any](messages <-chan amqp.Delivery, handler func(T) Ack
 	}
 }
 
-func workerGobRoutine[T any](messages <-chan amqp.Delivery, handler func(T) AckType) {
+func workerGobRoutine[T any](messages <-chan amqp.Delivery, verify Verifier, handler func(string, T) AckType) {
 	for msg := range messages {
+		if !verified(verify, msg) {
+			log.Printf("Dropping unsigned or forged message on %s", msg.RoutingKey)
+			msg.Nack(false, false)
+			continue
+		}
 		var singleMessage T
 		err := gobUnmarshaller(msg.Body, &singleMessage)
 		if err != nil {
@@ -88,7 +139,7 @@ func workerGobRoutine[T any](messages <-chan amqp.Delivery, handler func(T) AckT
 			return
 		}
 
-		ackType := handler(singleMessage)
+		ackType := handler(msg.RoutingKey, singleMessage)
 
 		if ackType == Ack {
 			msg.Ack(false)
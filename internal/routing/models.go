@@ -2,8 +2,126 @@ package routing
 
 import "time"
 
+// PauseTargetAll pauses or resumes every game on the server.
+const PauseTargetAll = "all"
+
+// PlayingState pauses or resumes play. Target is PauseTargetAll, a game ID
+// or a username, and an empty Target means the whole game the message was
+// sent to. A pause with ResumeAt set ends on its own at that time.
 type PlayingState struct {
 	IsPaused bool
+	Target   string
+	Reason   string
+	ResumeAt time.Time
+}
+
+const (
+	PhaseReinforce = "reinforce"
+	PhaseMove      = "move"
+	PhaseCombat    = "combat"
+)
+
+type TurnState struct {
+	Enabled      bool
+	Round        int
+	Phase        string
+	ActivePlayer string
+	Deadline     time.Time
+}
+
+type TurnEnd struct {
+	Username string
+	Round    int
+	Phase    string
+}
+
+type Standing struct {
+	Username    string
+	Territories int
+	Eliminated  bool
+}
+
+type PlayerEliminated struct {
+	Username string
+	Reason   string
+}
+
+type GameOver struct {
+	Winner    string
+	Reason    string
+	Standings []Standing
+}
+
+type NewGame struct {
+	GameNumber int
+	StartedAt  time.Time
+	CombatMode string
+}
+
+const (
+	ChatChannelGlobal  = "global"
+	ChatChannelPrivate = "private"
+	ChatChannelTeam    = "team"
+	ChatChannelHistory = "history"
+
+	// ChatChannelJoinTeam tells a player which team the server put them on.
+	// An empty Team takes them off their team.
+	ChatChannelJoinTeam = "join_team"
+)
+
+type ChatMessage struct {
+	From    string
+	To      string
+	Team    string
+	Channel string
+	Text    string
+	SentAt  time.Time
+	History bool
+}
+
+// HeartbeatInterval is how often clients tell the server they are still
+// connected. The server drops players it has not heard from in a while.
+const HeartbeatInterval = 10 * time.Second
+
+const (
+	PresenceJoin      = "join"
+	PresenceHeartbeat = "heartbeat"
+	PresenceLeave     = "leave"
+	PresenceDrop      = "drop"
+)
+
+// Presence is sent by clients as join, heartbeat and leave, and broadcast by
+// the server as join, leave and drop.
+type Presence struct {
+	Username string
+	Status   string
+	At       time.Time
+}
+
+const (
+	AdminKick   = "kick"
+	AdminBan    = "ban"
+	AdminMute   = "mute"
+	AdminUnmute = "unmute"
+)
+
+// AdminEvent is a moderation action broadcast to every player. Until is
+// only set for mutes.
+type AdminEvent struct {
+	Action   string
+	Username string
+	Reason   string
+	Until    time.Time
+}
+
+// Announcement is a message from the server operator. It is sent to
+// AnnouncementKey for everyone, or to AnnouncementPrivatePrefix.<username>
+// when To names a single player.
+type Announcement struct {
+	From   string
+	To     string
+	Text   string
+	SentAt time.Time
 }
 
 type GameLog struct {
@@ -11,3 +129,63 @@ type GameLog struct {
 	Message     string
 	Username    string
 }
+
+const (
+	LobbyCreate = "create"
+	LobbyList   = "list"
+	LobbyJoin   = "join"
+)
+
+type LobbyRequest struct {
+	Action   string
+	GameID   string
+	Username string
+	Token    string
+}
+
+type GameInfo struct {
+	ID         string
+	GameNumber int
+	Players    []string
+	Paused     bool
+	CreatedAt  time.Time
+}
+
+type LobbyResponse struct {
+	Games []GameInfo
+	Game  GameInfo
+	// Pauses are the pauses the joining player is under, with their reasons
+	// and resume times.
+	Pauses []PlayingState
+	// Team is the team the server put the joining player on, if any.
+	Team string
+	// Units is the server's unit catalog. Players use it in place of their
+	// own so that everyone fights with the same stats.
+	Units []UnitType
+	Error string
+}
+
+// UnitType is one entry of a unit catalog.
+type UnitType struct {
+	Rank      string
+	Attack    int
+	Defense   int
+	HitPoints int
+	Movement  int
+	Cost      int
+	Abilities []string
+}
+
+// AuthRequest signs a player in with either their password or the token
+// from an earlier sign in. Unknown usernames are registered with Password.
+type AuthRequest struct {
+	Username string
+	Password string
+	Token    string
+}
+
+type AuthResponse struct {
+	Token      string
+	Registered bool
+	Error      string
+}
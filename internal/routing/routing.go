@@ -3,13 +3,95 @@ package routing
 const (
 	ArmyMovesPrefix = "army_moves"
 
+	VisibleMovesPrefix = "army_moves_visible"
+
+	ArmyStatePrefix = "army_state"
+
 	WarRecognitionsPrefix = "war"
 
+	WarDeclaredPrefix = "war_declared"
+
+	WarResultsPrefix = "war_results"
+
+	VisibleWarResultsPrefix = "war_results_visible"
+
+	TerritoryPrefix = "territory"
+
+	DiplomacyPrefix = "diplomacy"
+
+	ChatInPrefix = "chat_in"
+
+	ChatGlobalKey = "chat.global"
+
+	ChatPrivatePrefix = "chat.private"
+
 	PauseKey = "pause"
 
+	TurnKey = "turn"
+
+	TurnEndPrefix = "turn_end"
+
+	EliminatedKey = "eliminated"
+
+	GameOverKey = "game_over"
+
+	NewGameKey = "new_game"
+
 	GameLogSlug = "game_logs"
+
+	HeartbeatPrefix = "heartbeat"
+
+	PresenceKey = "presence"
+
+	AdminKey = "admin"
+
+	AnnouncementKey = "announce"
+
+	AnnouncementPrivatePrefix = "announce"
+
+	LobbyKey = "lobby"
+
+	AuthKey = "auth"
 )
 
+// DefaultGameID is the game players land in when they do not pick one.
+const DefaultGameID = "default"
+
+// ServerUsername signs chat notices, announcements and logs written by the
+// server. No player may sign in under it.
+const ServerUsername = "server"
+
+// GameKey namespaces a routing key or queue name by game, so several games
+// can share one broker without seeing each other's messages.
+func GameKey(gameID, key string) string {
+	return gameID + "." + key
+}
+
+// IsValidGameID reports whether id is safe to use inside routing keys: no
+// dots or topic wildcards, and short enough to read in a queue list.
+func IsValidGameID(id string) bool {
+	return isValidName(id)
+}
+
+// IsValidUsername reports whether username is safe to use as a routing key
+// segment, in queue names and in file names. A player called "#" or "*"
+// would otherwise be bound to every other player's private queues.
+func IsValidUsername(username string) bool {
+	return isValidName(username)
+}
+
+func isValidName(name string) bool {
+	if name == "" || len(name) > 32 {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
 const (
 	ExchangePerilDirect = "peril_direct"
 	ExchangePerilTopic  = "peril_topic"
@@ -0,0 +1,185 @@
+package sim
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+)
+
+type StrategyStats struct {
+	Games       int
+	Wins        int
+	Draws       int
+	GoldSpent   int
+	DamageDealt int
+}
+
+func (s StrategyStats) WinRate() float64 {
+	if s.Games == 0 {
+		return 0
+	}
+	return float64(s.Wins) / float64(s.Games)
+}
+
+type RankStats struct {
+	Spawned     int
+	Killed      int
+	GoldSpent   int
+	DamageDealt int
+}
+
+// Efficiency is the enemy hit points destroyed per gold spent on the rank.
+func (s RankStats) Efficiency() float64 {
+	if s.GoldSpent == 0 {
+		return 0
+	}
+	return float64(s.DamageDealt) / float64(s.GoldSpent)
+}
+
+func (s RankStats) SurvivalRate() float64 {
+	if s.Spawned == 0 {
+		return 0
+	}
+	return 1 - float64(s.Killed)/float64(s.Spawned)
+}
+
+type Report struct {
+	Games      int
+	Wars       int
+	Strategies map[string]*StrategyStats
+	Ranks      map[gamelogic.UnitRank]*RankStats
+	// strategyOf maps the usernames of the game being played to strategies.
+	strategyOf map[string]string
+}
+
+func newReport() *Report {
+	return &Report{
+		Strategies: map[string]*StrategyStats{},
+		Ranks:      map[gamelogic.UnitRank]*RankStats{},
+	}
+}
+
+func (r *Report) strategy(name string) *StrategyStats {
+	if _, ok := r.Strategies[name]; !ok {
+		r.Strategies[name] = &StrategyStats{}
+	}
+	return r.Strategies[name]
+}
+
+func (r *Report) rank(rank gamelogic.UnitRank) *RankStats {
+	if _, ok := r.Ranks[rank]; !ok {
+		r.Ranks[rank] = &RankStats{}
+	}
+	return r.Ranks[rank]
+}
+
+func (r *Report) startGame(players []*simPlayer) {
+	r.Games++
+	r.strategyOf = map[string]string{}
+	for _, p := range players {
+		r.strategyOf[p.gs.GetUsername()] = p.strategy
+		r.strategy(p.strategy).Games++
+	}
+}
+
+// finishGame credits a win when there is a single winner and a draw to
+// every tied player otherwise.
+func (r *Report) finishGame(players []*simPlayer, winners []*simPlayer) {
+	if len(winners) == 1 {
+		r.strategy(winners[0].strategy).Wins++
+		return
+	}
+	for _, p := range winners {
+		r.strategy(p.strategy).Draws++
+	}
+}
+
+func (r *Report) recordSpawn(p *simPlayer, rank gamelogic.UnitRank) {
+	ut, _ := gamelogic.GetUnitType(rank)
+	r.rank(rank).Spawned++
+	r.rank(rank).GoldSpent += ut.Cost
+	r.strategy(p.strategy).GoldSpent += ut.Cost
+}
+
+// recordWar credits the damage each side dealt to its ranks in proportion
+// to how much power each unit brought to the battle.
+func (r *Report) recordWar(players []*simPlayer, rw gamelogic.RecognitionOfWar, result gamelogic.WarResult) {
+	r.Wars++
+	for _, battle := range result.Battles {
+		damageTo := map[string]int{}
+		for _, casualty := range battle.Casualties {
+			damageTo[casualty.Owner] += casualty.Damage
+			if casualty.Killed {
+				r.rank(casualty.Rank).Killed++
+			}
+		}
+		r.creditDamage(unitsIn(rw.Attacker, battle.Location), damageTo[rw.Defender.Username], true)
+		r.creditDamage(unitsIn(rw.Defender, battle.Location), damageTo[rw.Attacker.Username], false)
+		r.strategy(r.strategyOf[rw.Attacker.Username]).DamageDealt += damageTo[rw.Defender.Username]
+		r.strategy(r.strategyOf[rw.Defender.Username]).DamageDealt += damageTo[rw.Attacker.Username]
+	}
+}
+
+func (r *Report) creditDamage(units []gamelogic.Unit, damage int, attacking bool) {
+	power := func(units []gamelogic.Unit) int {
+		if attacking {
+			return gamelogic.AttackPower(units)
+		}
+		return gamelogic.DefensePower(units)
+	}
+	total := power(units)
+	if total == 0 {
+		return
+	}
+	for _, unit := range units {
+		r.rank(unit.Rank).DamageDealt += damage * power([]gamelogic.Unit{unit}) / total
+	}
+}
+
+func unitsIn(p gamelogic.Player, loc gamelogic.Location) []gamelogic.Unit {
+	units := []gamelogic.Unit{}
+	for _, unit := range p.Units {
+		if unit.Location == loc {
+			units = append(units, unit)
+		}
+	}
+	return units
+}
+
+func (r *Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "%d games, %d wars\n\n", r.Games, r.Wars)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "strategy\tgames\twins\tdraws\twin rate\tgold spent\tdamage dealt\tdamage/gold")
+	strategies := []string{}
+	for name := range r.Strategies {
+		strategies = append(strategies, name)
+	}
+	sort.Strings(strategies)
+	for _, name := range strategies {
+		s := r.Strategies[name]
+		perGold := 0.0
+		if s.GoldSpent > 0 {
+			perGold = float64(s.DamageDealt) / float64(s.GoldSpent)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.1f%%\t%d\t%d\t%.2f\n", name, s.Games, s.Wins, s.Draws, s.WinRate()*100, s.GoldSpent, s.DamageDealt, perGold)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "rank\tspawned\tkilled\tsurvival\tgold spent\tdamage dealt\tdamage/gold")
+	ranks := []gamelogic.UnitRank{}
+	for rank := range r.Ranks {
+		ranks = append(ranks, rank)
+	}
+	sort.Slice(ranks, func(i, j int) bool { return ranks[i] < ranks[j] })
+	for _, rank := range ranks {
+		s := r.Ranks[rank]
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f%%\t%d\t%d\t%.2f\n", rank, s.Spawned, s.Killed, s.SurvivalRate()*100, s.GoldSpent, s.DamageDealt, s.Efficiency())
+	}
+	tw.Flush()
+}
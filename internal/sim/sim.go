@@ -0,0 +1,202 @@
+package sim
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/bot"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/routing"
+)
+
+// Config describes a batch of simulated games. Every game seats one bot per
+// entry in Strategies.
+type Config struct {
+	Games      int
+	Strategies []string
+	Difficulty bot.Difficulty
+	Rounds     int
+	Mode       gamelogic.CombatMode
+	Seed       int64
+}
+
+type simPlayer struct {
+	strategy string
+	gs       *gamelogic.GameState
+	bot      *bot.Bot
+}
+
+// Run plays cfg.Games games without a broker, using the same spawn, move
+// and war rules as networked games, and collects statistics. The same
+// config always produces the same report.
+func Run(cfg Config) (*Report, error) {
+	if len(cfg.Strategies) < 2 {
+		return nil, fmt.Errorf("a game needs at least two strategies")
+	}
+	if cfg.Rounds < 1 {
+		return nil, fmt.Errorf("a game needs at least one round")
+	}
+	for _, name := range cfg.Strategies {
+		if _, err := bot.NewStrategy(name); err != nil {
+			return nil, err
+		}
+	}
+
+	report := newReport()
+	for i := 0; i < cfg.Games; i++ {
+		rng := rand.New(rand.NewSource(cfg.Seed + int64(i)))
+		playGame(cfg, rng, report)
+	}
+	return report, nil
+}
+
+func playGame(cfg Config, rng *rand.Rand, report *Report) {
+	players := []*simPlayer{}
+	for i, name := range cfg.Strategies {
+		strategy, _ := bot.NewStrategy(name)
+		username := fmt.Sprintf("%s-%d", name, i+1)
+		gs := gamelogic.NewGameState(username)
+		gs.SetOutput(io.Discard)
+		gs.HandleNewGame(routing.NewGame{GameNumber: 1, CombatMode: string(cfg.Mode)})
+		players = append(players, &simPlayer{
+			strategy: name,
+			gs:       gs,
+			bot:      bot.New(gs, strategy, cfg.Difficulty, rng.Int63()),
+		})
+	}
+	report.startGame(players)
+
+	for round := 1; round <= cfg.Rounds; round++ {
+		order := rng.Perm(len(players))
+		for _, phase := range []string{routing.PhaseReinforce, routing.PhaseMove} {
+			for _, p := range players {
+				p.gs.HandleTurn(routing.TurnState{
+					Enabled: true,
+					Round:   round,
+					Phase:   phase,
+				})
+			}
+			for _, i := range order {
+				takeTurn(players[i], players, rng, report)
+			}
+		}
+		if alive := survivors(players); len(alive) <= 1 {
+			report.finishGame(players, alive)
+			return
+		}
+	}
+	report.finishGame(players, leaders(players))
+}
+
+func takeTurn(p *simPlayer, players []*simPlayer, rng *rand.Rand, report *Report) {
+	words := p.bot.Decide()
+	if words == nil {
+		return
+	}
+	switch words[0] {
+	case "spawn":
+		if err := p.gs.CommandSpawn(words); err != nil {
+			return
+		}
+		report.recordSpawn(p, gamelogic.UnitRank(words[2]))
+	case "move":
+		move, err := p.gs.CommandMove(words)
+		if err != nil {
+			return
+		}
+		p.gs.ApplyMove(move)
+		deliverMove(p, move, players, rng, report)
+	}
+	broadcastTerritories(players)
+}
+
+// deliverMove plays the part of the server and the other clients: each
+// player sees what fog of war allows and declares war on overlap, and the
+// mover resolves the war. The result reaches the defender in full and the
+// other players only for the battles they can see.
+func deliverMove(mover *simPlayer, move gamelogic.ArmyMove, players []*simPlayer, rng *rand.Rand, report *Report) {
+	for _, p := range players {
+		if p == mover {
+			continue
+		}
+		visible, ok := gamelogic.FilterMoveFor(move, p.gs.GetPlayerSnap())
+		if !ok || p.gs.HandleMove(visible) != gamelogic.MoveOutcomeMakeWar {
+			continue
+		}
+		rw := gamelogic.RecognitionOfWar{
+			Attacker: visible.Player,
+			Defender: gamelogic.FilterPlayerFor(p.gs.GetPlayerSnap(), visible.Player),
+			Mode:     p.gs.GetCombatMode(),
+			Seed:     rng.Int63(),
+		}
+		outcome, result := mover.gs.HandleWar(rw)
+		if outcome == gamelogic.WarOutcomeNotInvolved || outcome == gamelogic.WarOutcomeNoUnits {
+			continue
+		}
+		for _, other := range players {
+			if other == p {
+				other.gs.HandleWarResult(result)
+			} else if visible, ok := gamelogic.FilterWarResultFor(result, other.gs.GetPlayerSnap()); ok {
+				other.gs.HandleWarResult(visible)
+			}
+		}
+		report.recordWar(players, rw, result)
+		move.Player = mover.gs.GetPlayerSnap()
+	}
+}
+
+func broadcastTerritories(players []*simPlayer) {
+	for _, p := range players {
+		for _, tc := range p.gs.UpdateTerritories() {
+			for _, other := range players {
+				if other != p {
+					other.gs.HandleTerritoryChange(tc)
+				}
+			}
+		}
+	}
+}
+
+// survivors returns the players who still have units, territory or enough
+// gold to buy a unit.
+func survivors(players []*simPlayer) []*simPlayer {
+	cheapest := 0
+	if types := gamelogic.GetUnitTypes(); len(types) > 0 {
+		cheapest = types[0].Cost
+	}
+	alive := []*simPlayer{}
+	for _, p := range players {
+		owned := 0
+		for _, owner := range p.gs.GetTerritoriesSnap() {
+			if owner == p.gs.GetUsername() {
+				owned++
+			}
+		}
+		if len(p.gs.GetPlayerSnap().Units) > 0 || owned > 0 || p.gs.GetTreasury() >= cheapest {
+			alive = append(alive, p)
+		}
+	}
+	return alive
+}
+
+// leaders returns the players holding the most territory according to the
+// first player's map, which every client agrees on in the simulation.
+func leaders(players []*simPlayer) []*simPlayer {
+	counts := map[string]int{}
+	for _, owner := range players[0].gs.GetTerritoriesSnap() {
+		counts[owner]++
+	}
+	sorted := append([]*simPlayer{}, players...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return counts[sorted[i].gs.GetUsername()] > counts[sorted[j].gs.GetUsername()]
+	})
+	best := []*simPlayer{}
+	for _, p := range sorted {
+		if counts[p.gs.GetUsername()] == counts[sorted[0].gs.GetUsername()] {
+			best = append(best, p)
+		}
+	}
+	return best
+}
@@ -0,0 +1,114 @@
+package sim
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/bot"
+	"github.com/bootdotdev/learn-pub-sub-starter/internal/gamelogic"
+)
+
+func TestRunRejectsBadConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{
+			name: "one strategy",
+			cfg:  Config{Games: 1, Strategies: []string{"random"}, Rounds: 5},
+		},
+		{
+			name: "no rounds",
+			cfg:  Config{Games: 1, Strategies: []string{"random", "greedy"}},
+		},
+		{
+			name: "unknown strategy",
+			cfg:  Config{Games: 1, Strategies: []string{"random", "reckless"}, Rounds: 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Run(tt.cfg); err == nil {
+				t.Error("Run() accepted a bad config")
+			}
+		})
+	}
+}
+
+func TestRunIsDeterministic(t *testing.T) {
+	for _, mode := range []gamelogic.CombatMode{gamelogic.CombatModeProportional, gamelogic.CombatModeDice} {
+		t.Run(string(mode), func(t *testing.T) {
+			cfg := Config{
+				Games:      5,
+				Strategies: []string{"random", "greedy", "defensive"},
+				Difficulty: bot.DifficultyNormal,
+				Rounds:     20,
+				Mode:       mode,
+				Seed:       42,
+			}
+			first, err := Run(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			second, err := Run(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			first.strategyOf, second.strategyOf = nil, nil
+			if !reflect.DeepEqual(first, second) {
+				t.Errorf("the same config gave two reports:\n%+v\n%+v", first, second)
+			}
+		})
+	}
+}
+
+func TestRunReport(t *testing.T) {
+	cfg := Config{
+		Games:      10,
+		Strategies: []string{"greedy", "greedy", "defensive"},
+		Difficulty: bot.DifficultyHard,
+		Rounds:     30,
+		Mode:       gamelogic.CombatModeDice,
+		Seed:       7,
+	}
+	report, err := Run(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Games != cfg.Games {
+		t.Errorf("report has %d games, want %d", report.Games, cfg.Games)
+	}
+	if report.Wars == 0 {
+		t.Error("no wars were fought")
+	}
+
+	seats := map[string]int{"greedy": 2, "defensive": 1}
+	decided := 0
+	for name, stats := range report.Strategies {
+		if stats.Games != seats[name]*cfg.Games {
+			t.Errorf("%s played %d games, want %d", name, stats.Games, seats[name]*cfg.Games)
+		}
+		if stats.Wins+stats.Draws > stats.Games {
+			t.Errorf("%s won %d and drew %d of %d games", name, stats.Wins, stats.Draws, stats.Games)
+		}
+		decided += stats.Wins
+	}
+	if decided > cfg.Games {
+		t.Errorf("%d games were won, but only %d were played", decided, cfg.Games)
+	}
+
+	spent := 0
+	for _, stats := range report.Strategies {
+		spent += stats.GoldSpent
+	}
+	rankSpent := 0
+	for rank, stats := range report.Ranks {
+		if stats.Killed > stats.Spawned {
+			t.Errorf("%d %s were killed but only %d spawned", stats.Killed, rank, stats.Spawned)
+		}
+		rankSpent += stats.GoldSpent
+	}
+	if spent != rankSpent {
+		t.Errorf("strategies spent %d gold, ranks %d", spent, rankSpent)
+	}
+}